@@ -1138,17 +1138,24 @@ func (p *Planner) addField(ref int) {
 type Factory struct {
 	BatchFactory resolve.DataSourceBatchFactory
 	HTTPClient   *http.Client
-	wsClient     *WebSocketGraphQLSubscriptionClient
+	// SubscriptionClient is used to start subscriptions with the origin, e.g. a GraphQLTransportWSSubscriptionClient
+	// it defaults to a WebSocketGraphQLSubscriptionClient speaking the legacy graphql-ws protocol
+	SubscriptionClient GraphQLSubscriptionClient
+	wsClient           *WebSocketGraphQLSubscriptionClient
 }
 
 func (f *Factory) Planner(ctx context.Context) plan.DataSourcePlanner {
-	if f.wsClient == nil {
-		f.wsClient = NewWebSocketGraphQLSubscriptionClient(f.HTTPClient, ctx)
+	subscriptionClient := f.SubscriptionClient
+	if subscriptionClient == nil {
+		if f.wsClient == nil {
+			f.wsClient = NewWebSocketGraphQLSubscriptionClient(f.HTTPClient, ctx)
+		}
+		subscriptionClient = f.wsClient
 	}
 	return &Planner{
 		batchFactory:       f.BatchFactory,
 		fetchClient:        f.HTTPClient,
-		subscriptionClient: f.wsClient,
+		subscriptionClient: subscriptionClient,
 	}
 }
 
@@ -0,0 +1,186 @@
+package graphql_datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"nhooyr.io/websocket"
+)
+
+const (
+	transportWSProtocol         = "graphql-transport-ws"
+	transportWSSubscribeMessage = `{"id":"1","type":"subscribe","payload":%s}`
+	transportWSCompleteMessage  = `{"id":"1","type":"complete"}`
+	transportWSPongMessage      = `{"type":"pong"}`
+)
+
+const (
+	MessageTypeNext = "next"
+	MessageTypePing = "ping"
+	MessageTypePong = "pong"
+)
+
+// GraphQLTransportWSSubscriptionClient is a GraphQLSubscriptionClient speaking the graphql-transport-ws protocol
+// as implemented by the graphql-ws library.
+// Each subscription opens a dedicated WebSocket connection to the origin.
+// The payload of each "next" message is sent to the next channel,
+// "error" messages are sent as GraphQL response containing the errors.
+// The next channel is closed once the origin completes the subscription, the connection fails or the context is done.
+type GraphQLTransportWSSubscriptionClient struct {
+	httpClient *http.Client
+}
+
+func NewGraphQLTransportWSSubscriptionClient(httpClient *http.Client) *GraphQLTransportWSSubscriptionClient {
+	return &GraphQLTransportWSSubscriptionClient{
+		httpClient: httpClient,
+	}
+}
+
+// Subscribe dials the origin, awaits the connection_ack and sends the subscribe message
+// Once the subscription is started, messages are read in a separate goroutine until the stream terminates
+func (c *GraphQLTransportWSSubscriptionClient) Subscribe(ctx context.Context, options GraphQLSubscriptionOptions, next chan<- []byte) error {
+	graphQLBody, err := json.Marshal(options.Body)
+	if err != nil {
+		return err
+	}
+
+	conn, upgradeResponse, err := websocket.Dial(ctx, options.URL, &websocket.DialOptions{
+		HTTPClient:      c.httpClient,
+		HTTPHeader:      options.Header,
+		CompressionMode: websocket.CompressionDisabled,
+		Subprotocols:    []string{transportWSProtocol},
+	})
+	if err != nil {
+		return err
+	}
+	if upgradeResponse.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close(websocket.StatusProtocolError, "")
+		return fmt.Errorf("upgrade unsuccessful")
+	}
+
+	err = c.init(ctx, conn, graphQLBody)
+	if err != nil {
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+		return err
+	}
+
+	go c.readBlocking(ctx, conn, next)
+	return nil
+}
+
+func (c *GraphQLTransportWSSubscriptionClient) init(ctx context.Context, conn *websocket.Conn, graphQLBody []byte) error {
+	err := conn.Write(ctx, websocket.MessageText, connectionInitMessage)
+	if err != nil {
+		return err
+	}
+
+	ackCtx, cancel := context.WithTimeout(ctx, ackWaitTimeout)
+	defer cancel()
+
+	for {
+		data, err := c.read(ackCtx, conn)
+		if err != nil {
+			return err
+		}
+		messageType, err := jsonparser.GetString(data, "type")
+		if err != nil {
+			return err
+		}
+		switch messageType {
+		case MessageTypePing:
+			err = conn.Write(ctx, websocket.MessageText, []byte(transportWSPongMessage))
+			if err != nil {
+				return err
+			}
+			continue
+		case MessageTypePong:
+			continue
+		case MessageTypeConnectionAck:
+			return conn.Write(ctx, websocket.MessageText, []byte(fmt.Sprintf(transportWSSubscribeMessage, graphQLBody)))
+		default:
+			return fmt.Errorf("expected connection_ack, got %s", messageType)
+		}
+	}
+}
+
+// readBlocking forwards all messages of the subscription to next until the stream terminates
+// if the context is done, the subscription is completed and the connection is closed
+func (c *GraphQLTransportWSSubscriptionClient) readBlocking(ctx context.Context, conn *websocket.Conn, next chan<- []byte) {
+	defer close(next)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	// reading with ctx would close the connection without completing the subscription once ctx is done
+	go func() {
+		select {
+		case <-ctx.Done():
+			writeCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+			_ = conn.Write(writeCtx, websocket.MessageText, []byte(transportWSCompleteMessage))
+			cancel()
+			_ = conn.Close(websocket.StatusNormalClosure, "")
+		case <-done:
+		}
+	}()
+
+	for {
+		data, err := c.read(context.Background(), conn)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.send(ctx, next, []byte(connectionError))
+			_ = conn.Close(websocket.StatusNormalClosure, "")
+			return
+		}
+		messageType, err := jsonparser.GetString(data, "type")
+		if err != nil {
+			continue
+		}
+		switch messageType {
+		case MessageTypeNext:
+			payload, _, _, err := jsonparser.Get(data, "payload")
+			if err != nil {
+				continue
+			}
+			c.send(ctx, next, payload)
+		case MessageTypeError:
+			payload, valueType, _, err := jsonparser.Get(data, "payload")
+			if err != nil || valueType != jsonparser.Array {
+				c.send(ctx, next, []byte(internalError))
+			} else {
+				c.send(ctx, next, []byte(fmt.Sprintf(`{"errors":%s}`, payload)))
+			}
+			_ = conn.Close(websocket.StatusNormalClosure, "")
+			return
+		case MessageTypeComplete:
+			_ = conn.Close(websocket.StatusNormalClosure, "")
+			return
+		case MessageTypePing:
+			_ = conn.Write(ctx, websocket.MessageText, []byte(transportWSPongMessage))
+		}
+	}
+}
+
+func (c *GraphQLTransportWSSubscriptionClient) read(ctx context.Context, conn *websocket.Conn) ([]byte, error) {
+	for {
+		messageType, data, err := conn.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if messageType == websocket.MessageText {
+			return data, nil
+		}
+	}
+}
+
+func (c *GraphQLTransportWSSubscriptionClient) send(ctx context.Context, next chan<- []byte, data []byte) {
+	select {
+	case next <- data:
+	case <-ctx.Done():
+	}
+}
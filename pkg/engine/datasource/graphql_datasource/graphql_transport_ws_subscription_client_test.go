@@ -0,0 +1,131 @@
+package graphql_datasource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+)
+
+func transportWSServer(t *testing.T, handle func(ctx context.Context, conn *websocket.Conn)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+			Subprotocols: []string{"graphql-transport-ws"},
+		})
+		require.NoError(t, err)
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		assert.Equal(t, "graphql-transport-ws", conn.Subprotocol())
+
+		ctx := context.Background()
+		msgType, data, err := conn.Read(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, websocket.MessageText, msgType)
+		assert.Equal(t, `{"type":"connection_init"}`, string(data))
+
+		require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(`{"type":"ping"}`)))
+		_, data, err = conn.Read(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"pong"}`, string(data))
+
+		require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(`{"type":"connection_ack"}`)))
+
+		_, data, err = conn.Read(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, `{"id":"1","type":"subscribe","payload":{"query":"subscription {messageAdded(roomName: \"room\"){text}}"}}`, string(data))
+
+		handle(ctx, conn)
+	}))
+}
+
+func subscribeTransportWS(t *testing.T, ctx context.Context, server *httptest.Server) chan []byte {
+	client := NewGraphQLTransportWSSubscriptionClient(http.DefaultClient)
+	next := make(chan []byte)
+	err := client.Subscribe(ctx, GraphQLSubscriptionOptions{
+		URL: strings.Replace(server.URL, "http", "ws", 1),
+		Body: GraphQLBody{
+			Query: `subscription {messageAdded(roomName: "room"){text}}`,
+		},
+	}, next)
+	require.NoError(t, err)
+	return next
+}
+
+func assertClosed(t *testing.T, next chan []byte) {
+	select {
+	case data, ok := <-next:
+		assert.False(t, ok, "unexpected message: %s", string(data))
+	case <-time.After(time.Second):
+		t.Fatal("next was not closed")
+	}
+}
+
+func TestGraphQLTransportWSSubscriptionClient(t *testing.T) {
+	t.Run("next and complete", func(t *testing.T) {
+		server := transportWSServer(t, func(ctx context.Context, conn *websocket.Conn) {
+			require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(`{"id":"1","type":"next","payload":{"data":{"messageAdded":{"text":"first"}}}}`)))
+			require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(`{"id":"1","type":"next","payload":{"data":{"messageAdded":{"text":"second"}}}}`)))
+			require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(`{"id":"1","type":"complete"}`)))
+			_, _, _ = conn.Read(ctx)
+		})
+		defer server.Close()
+
+		next := subscribeTransportWS(t, context.Background(), server)
+		assert.Equal(t, `{"data":{"messageAdded":{"text":"first"}}}`, string(<-next))
+		assert.Equal(t, `{"data":{"messageAdded":{"text":"second"}}}`, string(<-next))
+		assertClosed(t, next)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		server := transportWSServer(t, func(ctx context.Context, conn *websocket.Conn) {
+			require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(`{"id":"1","type":"error","payload":[{"message":"room does not exist"}]}`)))
+			_, _, _ = conn.Read(ctx)
+		})
+		defer server.Close()
+
+		next := subscribeTransportWS(t, context.Background(), server)
+		assert.Equal(t, `{"errors":[{"message":"room does not exist"}]}`, string(<-next))
+		assertClosed(t, next)
+	})
+
+	t.Run("connection error", func(t *testing.T) {
+		server := transportWSServer(t, func(ctx context.Context, conn *websocket.Conn) {
+			require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(`{"id":"1","type":"next","payload":{"data":{"messageAdded":{"text":"first"}}}}`)))
+		})
+		defer server.Close()
+
+		next := subscribeTransportWS(t, context.Background(), server)
+		assert.Equal(t, `{"data":{"messageAdded":{"text":"first"}}}`, string(<-next))
+		assert.Equal(t, `{"errors":[{"message":"connection error"}]}`, string(<-next))
+		assertClosed(t, next)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		serverDone := make(chan struct{})
+		server := transportWSServer(t, func(ctx context.Context, conn *websocket.Conn) {
+			defer close(serverDone)
+			require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(`{"id":"1","type":"next","payload":{"data":{"messageAdded":{"text":"first"}}}}`)))
+			_, data, err := conn.Read(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, `{"id":"1","type":"complete"}`, string(data))
+		})
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		next := subscribeTransportWS(t, ctx, server)
+		assert.Equal(t, `{"data":{"messageAdded":{"text":"first"}}}`, string(<-next))
+		cancel()
+		assertClosed(t, next)
+
+		select {
+		case <-serverDone:
+		case <-time.After(time.Second):
+			t.Fatal("server did not receive complete")
+		}
+	})
+}
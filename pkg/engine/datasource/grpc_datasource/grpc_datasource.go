@@ -0,0 +1,74 @@
+package grpc_datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// Source is a DataSource invoking a unary gRPC method.
+// The fetch input is unmarshalled into the request message of the method using the protobuf JSON mapping,
+// the response message is written as {"data":<response>}.
+// gRPC status errors are written as GraphQL error carrying the status code in its extensions,
+// so fetches using Source must enable resolve.ProcessResponseConfig.ExtractGraphqlResponse.
+type Source struct {
+	conn       grpc.ClientConnInterface
+	method     protoreflect.MethodDescriptor
+	fullMethod string
+}
+
+func NewSource(conn grpc.ClientConnInterface, method protoreflect.MethodDescriptor) *Source {
+	return &Source{
+		conn:       conn,
+		method:     method,
+		fullMethod: fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name()),
+	}
+}
+
+// UniqueIdentifier is the full gRPC method name, e.g. /users.Users/GetUser
+func (s *Source) UniqueIdentifier() []byte {
+	return []byte(s.fullMethod)
+}
+
+func (s *Source) Load(ctx context.Context, input []byte, w io.Writer) (err error) {
+	request := dynamicpb.NewMessage(s.method.Input())
+	if len(input) != 0 {
+		if err = protojson.Unmarshal(input, request); err != nil {
+			return err
+		}
+	}
+
+	response := dynamicpb.NewMessage(s.method.Output())
+	err = s.conn.Invoke(ctx, s.fullMethod, request, response)
+	if err != nil {
+		st, ok := status.FromError(err)
+		if !ok {
+			return err
+		}
+		message, _ := json.Marshal(st.Message())
+		_, err = fmt.Fprintf(w, `{"errors":[{"message":%s,"extensions":{"code":"%s"}}]}`, message, st.Code())
+		return err
+	}
+
+	data, err := protojson.Marshal(response)
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write([]byte(`{"data":`)); err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	_, err = w.Write(literal.RBRACE)
+	return err
+}
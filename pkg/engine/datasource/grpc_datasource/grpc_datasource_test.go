@@ -0,0 +1,224 @@
+package grpc_datasource
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+)
+
+func usersService(t *testing.T) protoreflect.ServiceDescriptor {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("users.proto"),
+		Package: proto.String("users"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("GetUserRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("id"),
+						JsonName: proto.String("id"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+				},
+			},
+			{
+				Name: proto.String("User"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("id"),
+						JsonName: proto.String("id"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+					{
+						Name:     proto.String("name"),
+						JsonName: proto.String("name"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Users"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("GetUser"),
+						InputType:  proto.String(".users.GetUserRequest"),
+						OutputType: proto.String(".users.User"),
+					},
+					{
+						Name:       proto.String("GetManager"),
+						InputType:  proto.String(".users.GetUserRequest"),
+						OutputType: proto.String(".users.User"),
+					},
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	return file.Services().ByName("Users")
+}
+
+// startUsersServer serves all methods of the service, GetUser responds with user Jens and GetManager with user Stefan
+func startUsersServer(t *testing.T, service protoreflect.ServiceDescriptor, delay time.Duration) *grpc.ClientConn {
+	names := map[protoreflect.Name]string{
+		"GetUser":    "Jens",
+		"GetManager": "Stefan",
+	}
+	methods := make([]grpc.MethodDesc, 0, service.Methods().Len())
+	for i := 0; i < service.Methods().Len(); i++ {
+		method := service.Methods().Get(i)
+		methods = append(methods, grpc.MethodDesc{
+			MethodName: string(method.Name()),
+			Handler: func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				request := dynamicpb.NewMessage(method.Input())
+				if err := dec(request); err != nil {
+					return nil, err
+				}
+				time.Sleep(delay)
+				id := request.Get(method.Input().Fields().ByName("id")).String()
+				if id != "1" {
+					return nil, status.Errorf(codes.NotFound, "user %q not found", id)
+				}
+				user := dynamicpb.NewMessage(method.Output())
+				user.Set(method.Output().Fields().ByName("id"), protoreflect.ValueOfString(id))
+				user.Set(method.Output().Fields().ByName("name"), protoreflect.ValueOfString(names[method.Name()]))
+				return user, nil
+			},
+		})
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: string(service.FullName()),
+		HandlerType: (*interface{})(nil),
+		Methods:     methods,
+	}, struct{}{})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func TestSource_Load(t *testing.T) {
+	service := usersService(t)
+	method := service.Methods().ByName("GetUser")
+	source := NewSource(startUsersServer(t, service, 0), method)
+
+	t.Run("unique identifier", func(t *testing.T) {
+		assert.Equal(t, "/users.Users/GetUser", string(source.UniqueIdentifier()))
+	})
+
+	t.Run("successful response", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"id":"1"}`), out)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"data":{"id":"1","name":"Jens"}}`, out.String())
+	})
+
+	t.Run("status error", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"id":"2"}`), out)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"errors":[{"message":"user \"2\" not found","extensions":{"code":"NotFound"}}]}`, out.String())
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"unknown":"1"}`), out)
+		assert.Error(t, err)
+		assert.Equal(t, 0, out.Len())
+	})
+}
+
+func TestSource_SingleFlight(t *testing.T) {
+	service := usersService(t)
+	// the calls overlap, so they would be deduplicated if their keys were equal
+	conn := startUsersServer(t, service, 50*time.Millisecond)
+
+	rCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := resolve.New(rCtx, resolve.NewFetcher(true), false)
+
+	fetch := func(bufferID int, method protoreflect.Name) *resolve.SingleFetch {
+		return &resolve.SingleFetch{
+			BufferId:   bufferID,
+			DataSource: NewSource(conn, service.Methods().ByName(method)),
+			InputTemplate: resolve.InputTemplate{
+				Segments: []resolve.TemplateSegment{
+					{
+						SegmentType: resolve.StaticSegmentType,
+						Data:        []byte(`{"id":"1"}`),
+					},
+				},
+			},
+			ProcessResponseConfig: resolve.ProcessResponseConfig{ExtractGraphqlResponse: true},
+		}
+	}
+	field := func(name string, bufferID int) *resolve.Field {
+		return &resolve.Field{
+			Name:      []byte(name),
+			HasBuffer: true,
+			BufferID:  bufferID,
+			Value: &resolve.String{
+				Path: []string{"name"},
+			},
+		}
+	}
+
+	response := &resolve.GraphQLResponse{
+		Data: &resolve.Object{
+			Fetch: &resolve.ParallelFetch{
+				Fetches: []resolve.Fetch{
+					fetch(0, "GetUser"),
+					fetch(1, "GetManager"),
+				},
+			},
+			Fields: []*resolve.Field{
+				field("user", 0),
+				field("manager", 1),
+			},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	err := resolver.ResolveGraphQLResponse(resolve.NewContext(context.Background()), response, nil, out)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"data":{"user":"Jens","manager":"Stefan"}}`, out.String())
+}
@@ -0,0 +1,109 @@
+package http_datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	byte_template "github.com/jensneuse/byte-template"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// Configuration configures the request of a Source.
+// URL and the values of Header may contain placeholders like {{ .user.id }},
+// which are replaced with the value at the path user.id of the fetch input.
+type Configuration struct {
+	Method string
+	URL    string
+	Header http.Header
+}
+
+// Source is a DataSource sending the fetch input as request body using an http.RoundTripper.
+// A successful (2xx) response body is written as {"data":<body>}, any other status code is written as GraphQL error,
+// so fetches using Source must enable resolve.ProcessResponseConfig.ExtractGraphqlResponse.
+type Source struct {
+	roundTripper http.RoundTripper
+	config       Configuration
+	identifier   []byte
+}
+
+func NewSource(roundTripper http.RoundTripper, config Configuration) *Source {
+	return &Source{
+		roundTripper: roundTripper,
+		config:       config,
+		identifier:   []byte(config.Method + " " + config.URL),
+	}
+}
+
+// UniqueIdentifier is derived from the method and the URL template
+func (s *Source) UniqueIdentifier() []byte {
+	return s.identifier
+}
+
+func (s *Source) Load(ctx context.Context, input []byte, w io.Writer) (err error) {
+	requestURL, err := renderTemplate(s.config.URL, input, url.PathEscape)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, s.config.Method, requestURL, bytes.NewReader(input))
+	if err != nil {
+		return err
+	}
+	for key, values := range s.config.Header {
+		for i := range values {
+			value, err := renderTemplate(values[i], input, nil)
+			if err != nil {
+				return err
+			}
+			request.Header.Add(key, value)
+		}
+	}
+
+	response, err := s.roundTripper.RoundTrip(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		message, _ := json.Marshal(fmt.Sprintf("unexpected status code: %d", response.StatusCode))
+		_, err = fmt.Fprintf(w, `{"errors":[{"message":%s,"extensions":{"statusCode":%d}}]}`, message, response.StatusCode)
+		return err
+	}
+
+	if _, err = w.Write([]byte(`{"data":`)); err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, response.Body); err != nil {
+		return err
+	}
+	_, err = w.Write(literal.RBRACE)
+	return err
+}
+
+func renderTemplate(template string, input []byte, escape func(string) string) (string, error) {
+	if !strings.Contains(template, "{{") {
+		return template, nil
+	}
+	out := &bytes.Buffer{}
+	_, err := byte_template.New().Execute(out, []byte(template), func(w io.Writer, path []byte) (n int, err error) {
+		path = bytes.TrimPrefix(bytes.TrimSpace(path), literal.DOT)
+		value, dataType, _, err := jsonparser.Get(input, strings.Split(string(path), ".")...)
+		if err != nil || dataType == jsonparser.Null {
+			return 0, nil
+		}
+		if escape != nil {
+			return w.Write([]byte(escape(string(value))))
+		}
+		return w.Write(value)
+	})
+	return out.String(), err
+}
@@ -0,0 +1,163 @@
+package http_datasource
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+)
+
+func TestSource_Load(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/users/1":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "Bearer 123", r.Header.Get("Authorization"))
+			assert.Equal(t, `{"id":"1","token":"123"}`, string(body))
+			_, _ = w.Write([]byte(`{"name":"Jens"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := NewSource(http.DefaultTransport, Configuration{
+		Method: http.MethodPost,
+		URL:    server.URL + "/users/{{ .id }}",
+		Header: http.Header{
+			"Authorization": []string{"Bearer {{ .token }}"},
+		},
+	})
+
+	t.Run("unique identifier", func(t *testing.T) {
+		assert.Equal(t, "POST "+server.URL+"/users/{{ .id }}", string(source.UniqueIdentifier()))
+	})
+
+	t.Run("successful response", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"id":"1","token":"123"}`), out)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"data":{"name":"Jens"}}`, out.String())
+	})
+
+	t.Run("non 2xx response", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"id":"2","token":"123"}`), out)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"errors":[{"message":"unexpected status code: 404","extensions":{"statusCode":404}}]}`, out.String())
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := resolve.New(rCtx, resolve.NewFetcher(false), false)
+
+		userResponse := func(id string) *resolve.GraphQLResponse {
+			return &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fetch: &resolve.SingleFetch{
+						BufferId:   0,
+						DataSource: source,
+						InputTemplate: resolve.InputTemplate{
+							Segments: []resolve.TemplateSegment{
+								{
+									SegmentType: resolve.StaticSegmentType,
+									Data:        []byte(`{"id":"` + id + `","token":"123"}`),
+								},
+							},
+						},
+						ProcessResponseConfig: resolve.ProcessResponseConfig{ExtractGraphqlResponse: true},
+					},
+					Fields: []*resolve.Field{
+						{
+							Name:      []byte("name"),
+							HasBuffer: true,
+							BufferID:  0,
+							Value: &resolve.String{
+								Path:     []string{"name"},
+								Nullable: true,
+							},
+						},
+					},
+				},
+			}
+		}
+
+		out := &bytes.Buffer{}
+		err := resolver.ResolveGraphQLResponse(resolve.NewContext(context.Background()), userResponse("1"), nil, out)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"data":{"name":"Jens"}}`, out.String())
+
+		out.Reset()
+		err = resolver.ResolveGraphQLResponse(resolve.NewContext(context.Background()), userResponse("2"), nil, out)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"errors":[{"message":"unexpected status code: 404","extensions":{"statusCode":404}}],"data":{"name":null}}`, out.String())
+	})
+}
+
+func TestSource_SingleFlight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the fetches overlap, so they would be deduplicated if their keys were equal
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"name":"` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	rCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := resolve.New(rCtx, resolve.NewFetcher(true), false)
+
+	fetch := func(bufferID int, path string) *resolve.SingleFetch {
+		return &resolve.SingleFetch{
+			BufferId:   bufferID,
+			DataSource: NewSource(http.DefaultTransport, Configuration{Method: http.MethodPost, URL: server.URL + path}),
+			InputTemplate: resolve.InputTemplate{
+				Segments: []resolve.TemplateSegment{
+					{
+						SegmentType: resolve.StaticSegmentType,
+						Data:        []byte(`{"id":1}`),
+					},
+				},
+			},
+			ProcessResponseConfig: resolve.ProcessResponseConfig{ExtractGraphqlResponse: true},
+		}
+	}
+	field := func(name string, bufferID int) *resolve.Field {
+		return &resolve.Field{
+			Name:      []byte(name),
+			HasBuffer: true,
+			BufferID:  bufferID,
+			Value: &resolve.String{
+				Path: []string{"name"},
+			},
+		}
+	}
+
+	response := &resolve.GraphQLResponse{
+		Data: &resolve.Object{
+			Fetch: &resolve.ParallelFetch{
+				Fetches: []resolve.Fetch{
+					fetch(0, "/users"),
+					fetch(1, "/orders"),
+				},
+			},
+			Fields: []*resolve.Field{
+				field("users", 0),
+				field("orders", 1),
+			},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	err := resolver.ResolveGraphQLResponse(resolve.NewContext(context.Background()), response, nil, out)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"data":{"users":"/users","orders":"/orders"}}`, out.String())
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/wundergraph/graphql-go-tools/internal/pkg/quotes"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
 	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
 )
 
@@ -195,4 +196,55 @@ func TestHttpClientDo(t *testing.T) {
 		input = SetInputURL(input, []byte(server.URL))
 		t.Run("net", runTest(background, input, `ok`))
 	})
+
+	t.Run("multipart upload", func(t *testing.T) {
+		body := []byte(`{"query":"mutation($file: Upload!, $files: [Upload!]!){upload(file: $file, files: $files)}","variables":{"file":{"$upload":1},"files":[{"$upload":0},{"$upload":1}]}}`)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, r.ParseMultipartForm(1024))
+			assert.Equal(t, `{"query":"mutation($file: Upload!, $files: [Upload!]!){upload(file: $file, files: $files)}","variables":{"file":null,"files":[null,null]}}`, r.FormValue("operations"))
+			assert.Equal(t, `{"0":["variables.files.0"],"1":["variables.file","variables.files.1"]}`, r.FormValue("map"))
+
+			for name, expected := range map[string]string{"0": "content of a", "1": "content of b"} {
+				file, header, err := r.FormFile(name)
+				assert.NoError(t, err)
+				content, err := ioutil.ReadAll(file)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, string(content))
+				assert.Equal(t, "text/plain", header.Header.Get("Content-Type"))
+			}
+			assert.Equal(t, "b.txt", r.MultipartForm.File["1"][0].Filename)
+
+			_, err := w.Write([]byte("ok"))
+			assert.NoError(t, err)
+		}))
+		defer server.Close()
+		var input []byte
+		input = SetInputMethod(input, []byte("POST"))
+		input = SetInputBody(input, body)
+		input = SetInputURL(input, []byte(server.URL))
+		ctx := resolve.ContextWithFiles(background, []resolve.File{
+			{Name: "a.txt", ContentType: "text/plain", Content: []byte("content of a")},
+			{Name: "b.txt", ContentType: "text/plain", Content: []byte("content of b")},
+		})
+		t.Run("net", runTest(ctx, input, `ok`))
+	})
+
+	t.Run("files without references", func(t *testing.T) {
+		body := []byte(`{"foo":"bar"}`)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			actualBody, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, string(body), string(actualBody))
+			_, err = w.Write([]byte("ok"))
+			assert.NoError(t, err)
+		}))
+		defer server.Close()
+		var input []byte
+		input = SetInputMethod(input, []byte("POST"))
+		input = SetInputBody(input, body)
+		input = SetInputURL(input, []byte(server.URL))
+		ctx := resolve.ContextWithFiles(background, []resolve.File{{Name: "a.txt", Content: []byte("a")}})
+		t.Run("net", runTest(ctx, input, `ok`))
+	})
 }
@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/tidwall/sjson"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// multipartBody turns a body containing references to uploaded files into a GraphQL multipart request,
+// see https://github.com/jaydenseric/graphql-multipart-request-spec.
+// The references are replaced with null in the "operations" part, the "map" part maps each file part to the paths of its references.
+// The body is returned unchanged if it doesn't reference any file.
+func multipartBody(body []byte, files []resolve.File) (multipartBody []byte, contentType string, err error) {
+	references := map[int][]string{}
+	var order []int
+	collectUploadReferences(body, nil, func(index int, path []string) {
+		if _, ok := references[index]; !ok {
+			order = append(order, index)
+		}
+		references[index] = append(references[index], strings.Join(path, "."))
+	})
+	if len(order) == 0 {
+		return body, "application/json", nil
+	}
+	sort.Ints(order)
+
+	operations := append([]byte(nil), body...)
+	fileMap := make(map[string][]string, len(order))
+	for _, index := range order {
+		if index < 0 || index >= len(files) {
+			return nil, "", fmt.Errorf("upload reference to missing file %d", index)
+		}
+		for _, path := range references[index] {
+			if operations, err = sjson.SetRawBytes(operations, path, literal.NULL); err != nil {
+				return nil, "", err
+			}
+		}
+		fileMap[strconv.Itoa(index)] = references[index]
+	}
+
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+	if err = writer.WriteField("operations", string(operations)); err != nil {
+		return nil, "", err
+	}
+	encodedFileMap, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = writer.WriteField("map", string(encodedFileMap)); err != nil {
+		return nil, "", err
+	}
+	for _, index := range order {
+		file := files[index]
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%d"; filename=%q`, index, file.Name))
+		if file.ContentType != "" {
+			header.Set("Content-Type", file.ContentType)
+		} else {
+			header.Set("Content-Type", "application/octet-stream")
+		}
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err = part.Write(file.Content); err != nil {
+			return nil, "", err
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// collectUploadReferences calls found with the path of each reference to an uploaded file in value, see resolve.UploadVariable
+func collectUploadReferences(value []byte, path []string, found func(index int, path []string)) {
+	if len(value) == 0 {
+		return
+	}
+	if index, ok := resolve.UploadReferenceIndex(value); ok {
+		found(index, path)
+		return
+	}
+	switch value[0] {
+	case '{':
+		_ = jsonparser.ObjectEach(value, func(key []byte, fieldValue []byte, dataType jsonparser.ValueType, _ int) error {
+			if dataType == jsonparser.Object || dataType == jsonparser.Array {
+				collectUploadReferences(fieldValue, append(path[:len(path):len(path)], string(key)), found)
+			}
+			return nil
+		})
+	case '[':
+		i := 0
+		_, _ = jsonparser.ArrayEach(value, func(item []byte, dataType jsonparser.ValueType, _ int, _ error) {
+			if dataType == jsonparser.Object || dataType == jsonparser.Array {
+				collectUploadReferences(item, append(path[:len(path):len(path)], strconv.Itoa(i)), found)
+			}
+			i++
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/buger/jsonparser"
 
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
 	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
 )
 
@@ -37,6 +38,13 @@ func Do(client *http.Client, ctx context.Context, requestInput []byte, out io.Wr
 
 	url, method, body, headers, queryParams := requestInputParams(requestInput)
 
+	contentType := "application/json"
+	if files := resolve.FilesFromContext(ctx); len(files) != 0 {
+		if body, contentType, err = multipartBody(body, files); err != nil {
+			return err
+		}
+	}
+
 	request, err := http.NewRequestWithContext(ctx, string(method), string(url), bytes.NewReader(body))
 	if err != nil {
 		return err
@@ -91,7 +99,7 @@ func Do(client *http.Client, ctx context.Context, requestInput []byte, out io.Wr
 	}
 
 	request.Header.Add("accept", "application/json")
-	request.Header.Add("content-type", "application/json")
+	request.Header.Add("content-type", contentType)
 
 	response, err := client.Do(request)
 	if err != nil {
@@ -12,6 +12,7 @@ import (
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/httpclient"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
 	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
 )
 
@@ -81,7 +82,27 @@ func (p *Planner) Register(visitor *plan.Visitor, configuration plan.DataSourceC
 	p.v = visitor
 	visitor.Walker.RegisterEnterFieldVisitor(p)
 	visitor.Walker.RegisterEnterOperationVisitor(p)
-	return json.Unmarshal(configuration.Custom, &p.config)
+	if err := json.Unmarshal(configuration.Custom, &p.config); err != nil {
+		return err
+	}
+	return p.config.Fetch.validateStaticInputs()
+}
+
+// validateStaticInputs rejects configured values which contain a variable placeholder, see resolve.ValidateStaticInput
+func (f *FetchConfiguration) validateStaticInputs() error {
+	inputs := []string{f.URL, f.Body}
+	for _, values := range f.Header {
+		inputs = append(inputs, values...)
+	}
+	for i := range f.Query {
+		inputs = append(inputs, f.Query[i].Value)
+	}
+	for i := range inputs {
+		if err := resolve.ValidateStaticInput(inputs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (p *Planner) EnterField(ref int) {
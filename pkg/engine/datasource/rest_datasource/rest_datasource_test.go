@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasourcetesting"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
@@ -1161,6 +1162,44 @@ func TestFastHttpJsonDataSourcePlanning(t *testing.T) {
 	))
 }
 
+func TestPlanner_RegisterRejectsVariablePlaceholders(t *testing.T) {
+	register := func(config FetchConfiguration) error {
+		walker := astvisitor.NewWalker(4)
+		planner := &Planner{}
+		return planner.Register(&plan.Visitor{Walker: &walker}, plan.DataSourceConfiguration{
+			Custom: ConfigJSON(Configuration{Fetch: config}),
+		}, false)
+	}
+
+	t.Run("templates and dollar signs", func(t *testing.T) {
+		err := register(FetchConfiguration{
+			URL:    "https://example.com/friends/{{ .arguments.id }}",
+			Method: "POST",
+			Body:   `{"price":"$$5","note":"$$abc$$"}`,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("placeholder in body", func(t *testing.T) {
+		err := register(FetchConfiguration{
+			URL:    "https://example.com/friends",
+			Method: "POST",
+			Body:   `{"note":"literally $$0$$"}`,
+		})
+		assert.ErrorIs(t, err, resolve.ErrInputContainsVariablePlaceholder)
+		assert.EqualError(t, err, "input contains a variable placeholder: $$0$$")
+	})
+
+	t.Run("placeholder in header", func(t *testing.T) {
+		err := register(FetchConfiguration{
+			URL:    "https://example.com/friends",
+			Method: "GET",
+			Header: http.Header{"X-Note": []string{"$$12$$"}},
+		})
+		assert.ErrorIs(t, err, resolve.ErrInputContainsVariablePlaceholder)
+	})
+}
+
 func TestHttpJsonDataSource_Load(t *testing.T) {
 	runTests := func(t *testing.T, source *Source) {
 		t.Run("simple get", func(t *testing.T) {
@@ -3,9 +3,11 @@ package staticdatasource
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
 )
 
 type Configuration struct {
@@ -40,7 +42,10 @@ func (p *Planner) DataSourcePlanningBehavior() plan.DataSourcePlanningBehavior {
 }
 
 func (p *Planner) Register(_ *plan.Visitor, configuration plan.DataSourceConfiguration, _ bool) error {
-	return json.Unmarshal(configuration.Custom, &p.config)
+	if err := json.Unmarshal(configuration.Custom, &p.config); err != nil {
+		return err
+	}
+	return resolve.ValidateStaticInput(p.config.Data)
 }
 
 func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
@@ -64,3 +69,28 @@ func (_ Source) Load(ctx context.Context, input []byte, w io.Writer) (err error)
 	_, err = w.Write(input)
 	return
 }
+
+// InMemorySource is a DataSource serving canned responses, e.g. for testing plans or serving constant data.
+// If DataByInput contains the input of a fetch, the corresponding response is returned,
+// otherwise Data is returned regardless of the input.
+type InMemorySource struct {
+	Identifier  []byte
+	Data        []byte
+	DataByInput map[string][]byte
+}
+
+func (s *InMemorySource) UniqueIdentifier() []byte {
+	return s.Identifier
+}
+
+func (s *InMemorySource) Load(ctx context.Context, input []byte, w io.Writer) (err error) {
+	if data, ok := s.DataByInput[string(input)]; ok {
+		_, err = w.Write(data)
+		return
+	}
+	if s.Data == nil {
+		return fmt.Errorf("staticdatasource: no data for input: %s", input)
+	}
+	_, err = w.Write(s.Data)
+	return
+}
@@ -1,8 +1,12 @@
 package staticdatasource
 
 import (
+	"bytes"
+	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasourcetesting"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
@@ -65,3 +69,65 @@ func TestStaticDataSourcePlanning(t *testing.T) {
 		},
 	))
 }
+
+func TestPlanner_RegisterRejectsVariablePlaceholders(t *testing.T) {
+	planner := &Planner{}
+	err := planner.Register(nil, plan.DataSourceConfiguration{
+		Custom: ConfigJSON(Configuration{Data: `{"note":"literally $$0$$"}`}),
+	}, false)
+	assert.ErrorIs(t, err, resolve.ErrInputContainsVariablePlaceholder)
+}
+
+func TestInMemorySource(t *testing.T) {
+	t.Run("constant", func(t *testing.T) {
+		source := &InMemorySource{
+			Identifier: []byte("hello"),
+			Data:       []byte(`{"hello":"world"}`),
+		}
+		assert.Equal(t, []byte("hello"), source.UniqueIdentifier())
+
+		for _, input := range []string{"", `{"id":1}`} {
+			out := &bytes.Buffer{}
+			err := source.Load(context.Background(), []byte(input), out)
+			assert.NoError(t, err)
+			assert.Equal(t, `{"hello":"world"}`, out.String())
+		}
+	})
+
+	t.Run("keyed by input", func(t *testing.T) {
+		source := &InMemorySource{
+			DataByInput: map[string][]byte{
+				`{"id":1}`: []byte(`{"name":"Jens"}`),
+				`{"id":2}`: []byte(`{"name":"Jannik"}`),
+			},
+		}
+
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"id":1}`), out)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"Jens"}`, out.String())
+
+		out.Reset()
+		err = source.Load(context.Background(), []byte(`{"id":2}`), out)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"Jannik"}`, out.String())
+
+		out.Reset()
+		err = source.Load(context.Background(), []byte(`{"id":3}`), out)
+		assert.EqualError(t, err, `staticdatasource: no data for input: {"id":3}`)
+	})
+
+	t.Run("keyed by input with default", func(t *testing.T) {
+		source := &InMemorySource{
+			Data: []byte(`null`),
+			DataByInput: map[string][]byte{
+				`{"id":1}`: []byte(`{"name":"Jens"}`),
+			},
+		}
+
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"id":3}`), out)
+		assert.NoError(t, err)
+		assert.Equal(t, `null`, out.String())
+	})
+}
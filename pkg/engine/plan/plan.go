@@ -531,6 +531,7 @@ func (v *Visitor) EnterField(ref int) {
 			IncludeDirectiveDefined: include,
 			IncludeVariableName:     includeVariableName,
 		}
+		v.currentField.Deduplicate = v.hasPreviousLeafField(v.currentField)
 		*v.currentFields[len(v.currentFields)-1].fields = append(*v.currentFields[len(v.currentFields)-1].fields, v.currentField)
 		return
 	}
@@ -577,6 +578,7 @@ func (v *Visitor) EnterField(ref int) {
 		IncludeDirectiveDefined: include,
 		IncludeVariableName:     includeVariableName,
 	}
+	v.currentField.Deduplicate = v.hasPreviousLeafField(v.currentField)
 
 	*v.currentFields[len(v.currentFields)-1].fields = append(*v.currentFields[len(v.currentFields)-1].fields, v.currentField)
 
@@ -680,6 +682,29 @@ func (v *Visitor) skipField(ref int) bool {
 	return false
 }
 
+// hasPreviousLeafField returns true if the current object already contains a leaf field with the response key of field.
+// This happens if a field is selected within an inline fragment and directly, e.g. { ... on Droid { name } name },
+// in which case the resolver only writes the first of both fields matching the data.
+func (v *Visitor) hasPreviousLeafField(field *resolve.Field) bool {
+	if !isLeafNode(field.Value) {
+		return false
+	}
+	for _, previous := range *v.currentFields[len(v.currentFields)-1].fields {
+		if bytes.Equal(previous.Name, field.Name) && isLeafNode(previous.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLeafNode(node resolve.Node) bool {
+	switch node.(type) {
+	case *resolve.Object, *resolve.Array:
+		return false
+	}
+	return true
+}
+
 func (v *Visitor) resolveFieldValue(fieldRef, typeRef int, nullable bool, path []string, isList bool) resolve.Node {
 	ofType := v.Definition.Types[typeRef].OfType
 
@@ -1209,6 +1234,9 @@ func (v *Visitor) configureObjectFetch(config objectFetchConfiguration) {
 func (v *Visitor) configureFetch(internal objectFetchConfiguration, external FetchConfiguration) resolve.Fetch {
 	dataSourceType := reflect.TypeOf(external.DataSource).String()
 	dataSourceType = strings.TrimPrefix(dataSourceType, "*")
+	if identifier, ok := external.DataSource.(resolve.UniqueIdentifier); ok {
+		dataSourceType = string(identifier.UniqueIdentifier())
+	}
 
 	singleFetch := &resolve.SingleFetch{
 		BufferId:              internal.bufferID,
@@ -0,0 +1,101 @@
+package resolve
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// apolloTracing records the timings of the fetches of a response, see WithApolloFetchTracing.
+// It's shared by the clones of a Context, so the fetches are guarded by mu.
+type apolloTracing struct {
+	start   time.Time
+	mu      sync.Mutex
+	fetches []apolloTracingFetch
+}
+
+type apolloTracingFetch struct {
+	path        [][]byte
+	startOffset time.Duration
+	duration    time.Duration
+}
+
+func newApolloTracing() *apolloTracing {
+	return &apolloTracing{
+		start: time.Now(),
+	}
+}
+
+// observeFetch must be deferred when a fetch is started, path is the tracingPath of the field the fetch was started at
+func (t *apolloTracing) observeFetch(path [][]byte, start time.Time) {
+	fetch := apolloTracingFetch{
+		path:        path,
+		startOffset: start.Sub(t.start),
+		duration:    time.Since(start),
+	}
+	t.mu.Lock()
+	t.fetches = append(t.fetches, fetch)
+	t.mu.Unlock()
+}
+
+// tracingPath copies the path elements of a Context without the leading data element,
+// they must be copied when the fetch is started as the elements are reused while resolving
+func tracingPath(pathElements [][]byte) [][]byte {
+	path := make([][]byte, 0, len(pathElements))
+	for i := range pathElements {
+		if i == 0 && bytes.Equal(literal.DATA, pathElements[0]) {
+			continue
+		}
+		path = append(path, append([]byte(nil), pathElements[i]...))
+	}
+	return path
+}
+
+// render returns the tracing as JSON object in the Apollo Tracing format version 1.
+// Each fetch is rendered as resolver of the field it was started at, the parentType and returnType are unknown to the Resolver and left empty.
+func (t *apolloTracing) render() []byte {
+	// end is derived from the monotonic duration, so endTime - startTime always equals the duration
+	duration := time.Since(t.start)
+	end := t.start.Add(duration)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracing := make([]byte, 0, 128+len(t.fetches)*128)
+	tracing = append(tracing, `{"version":1,"startTime":"`...)
+	tracing = t.start.UTC().AppendFormat(tracing, time.RFC3339Nano)
+	tracing = append(tracing, `","endTime":"`...)
+	tracing = end.UTC().AppendFormat(tracing, time.RFC3339Nano)
+	tracing = append(tracing, `","duration":`...)
+	tracing = strconv.AppendInt(tracing, int64(duration), 10)
+	tracing = append(tracing, `,"execution":{"resolvers":[`...)
+	for i, fetch := range t.fetches {
+		if i != 0 {
+			tracing = append(tracing, ',')
+		}
+		tracing = append(tracing, `{"path":[`...)
+		fieldName := []byte(nil)
+		for j, element := range fetch.path {
+			if j != 0 {
+				tracing = append(tracing, ',')
+			}
+			if _, err := strconv.Atoi(string(element)); err == nil {
+				tracing = append(tracing, element...)
+				continue
+			}
+			fieldName = element
+			tracing = strconv.AppendQuote(tracing, string(element))
+		}
+		tracing = append(tracing, `],"parentType":"","fieldName":`...)
+		tracing = strconv.AppendQuote(tracing, string(fieldName))
+		tracing = append(tracing, `,"returnType":"","startOffset":`...)
+		tracing = strconv.AppendInt(tracing, int64(fetch.startOffset), 10)
+		tracing = append(tracing, `,"duration":`...)
+		tracing = strconv.AppendInt(tracing, int64(fetch.duration), 10)
+		tracing = append(tracing, '}')
+	}
+	return append(tracing, `]}}`...)
+}
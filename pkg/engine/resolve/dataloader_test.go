@@ -78,7 +78,7 @@ func TestDataLoader_Load(t *testing.T) {
 				assert.Equal(t, expected, actual)
 				pair := NewBufPair()
 				pair.Data.WriteString(`{"me": {"id": "1234","username": "Me","__typename": "User"}}`)
-				return writeGraphqlResponse(pair, w, false)
+				return writeGraphqlResponse(pair, nil, w, false)
 			}).
 			Return(nil)
 
@@ -115,13 +115,13 @@ func TestDataLoader_Load(t *testing.T) {
 					assert.Equal(t, expected, actual)
 					pair := NewBufPair()
 					pair.Data.WriteString(`{"user": {"id":11, "username": "Username 11"}}`)
-					return writeGraphqlResponse(pair, w, false)
+					return writeGraphqlResponse(pair, nil, w, false)
 				case strings.Contains(actual, "22"):
 					expected := `{"method":"POST","url":"http://localhost:4001","body":{"query":"query($userId: ID!){user(id: $userId){ id username }","variables":{"userId":22}}`
 					assert.Equal(t, expected, actual)
 					pair := NewBufPair()
 					pair.Data.WriteString(`{"user": {"id":22, "username": "Username 22"}}`)
-					return writeGraphqlResponse(pair, w, false)
+					return writeGraphqlResponse(pair, nil, w, false)
 				}
 
 				return errors.New("unexpected call")
@@ -176,7 +176,7 @@ func TestDataLoader_Load(t *testing.T) {
 					assert.Equal(t, expected, actual)
 					pair := NewBufPair()
 					pair.Data.WriteString(`{"user": {"id":11, "username": "Username 11"}}`)
-					return writeGraphqlResponse(pair, w, false)
+					return writeGraphqlResponse(pair, nil, w, false)
 				case strings.Contains(actual, "22"):
 					return errors.New("failed to access http://localhost:4001")
 				}
@@ -339,13 +339,13 @@ func TestDataLoader_Load(t *testing.T) {
 					assert.Equal(t, expected, actual)
 					pair := NewBufPair()
 					pair.Data.WriteString(`{"user": {"id":11, "username": "Username 11"}}`)
-					return writeGraphqlResponse(pair, w, false)
+					return writeGraphqlResponse(pair, nil, w, false)
 				case strings.Contains(actual, "22"):
 					expected := `{"method":"POST","url":"http://localhost:4001","body":{"query":"query($userId: ID!){user(id: $userId){ id username }","variables":{"$userId":22}}`
 					assert.Equal(t, expected, actual)
 					pair := NewBufPair()
 					pair.Data.WriteString(`{"user": {"id":22, "username": "Username 22"}}`)
-					return writeGraphqlResponse(pair, w, false)
+					return writeGraphqlResponse(pair, nil, w, false)
 				}
 
 				return errors.New("unexpected call")
@@ -395,13 +395,13 @@ func TestDataLoader_Load(t *testing.T) {
 					assert.Equal(t, expected, actual)
 					pair := NewBufPair()
 					pair.Data.WriteString(`{"user": {"id":11, "username": "Username 11"}}`)
-					return writeGraphqlResponse(pair, w, false)
+					return writeGraphqlResponse(pair, nil, w, false)
 				case strings.Contains(actual, "22"):
 					expected := `{"method":"POST","url":"http://localhost:4001","body":{"query":"query($userId: ID!){user(id: $userId){ id username }","variables":{"$userId":22}}`
 					assert.Equal(t, expected, actual)
 					pair := NewBufPair()
 					pair.Data.WriteString(`{"user": {"id":22, "username": "Username 22"}}`)
-					return writeGraphqlResponse(pair, w, false)
+					return writeGraphqlResponse(pair, nil, w, false)
 				}
 
 				return errors.New("unexpected call")
@@ -483,7 +483,7 @@ func TestDataLoader_LoadBatch(t *testing.T) {
 				assert.Equal(t, expected, actual)
 				pair := NewBufPair()
 				pair.Data.WriteString(`[{"name": "Trilby"},{"name": "Fedora"}]`)
-				return writeGraphqlResponse(pair, w, false)
+				return writeGraphqlResponse(pair, nil, w, false)
 			}).
 			Return(nil)
 
@@ -549,7 +549,7 @@ func TestDataLoader_LoadBatch(t *testing.T) {
 				assert.Equal(t, expected, actual)
 				pair := NewBufPair()
 				pair.Data.WriteString(`[{"horsepower": 200},{"horsepower": 400}]`)
-				return writeGraphqlResponse(pair, w, false)
+				return writeGraphqlResponse(pair, nil, w, false)
 			}).
 			Return(nil)
 
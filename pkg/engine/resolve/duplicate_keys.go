@@ -0,0 +1,97 @@
+package resolve
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+)
+
+// DuplicateKeyPolicy defines which value is read if an object of the data of a fetch contains a key more than once
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysFirstWins reads the first value of a duplicate key, it's the behaviour of jsonparser and the default
+	DuplicateKeysFirstWins DuplicateKeyPolicy = iota
+	// DuplicateKeysLastWins reads the last value of a duplicate key, like most JSON decoders, e.g. encoding/json
+	DuplicateKeysLastWins
+	// DuplicateKeysError treats a duplicate key like a missing value: nullable fields are resolved to null,
+	// non-nullable fields fail with an error
+	DuplicateKeysError
+)
+
+// WithDuplicateKeyPolicy sets how duplicate keys in the data of fetches are handled, it defaults to DuplicateKeysFirstWins.
+// Only the keys of the paths read by the resolver are checked, duplicate keys elsewhere in the data are ignored.
+// Any other policy than DuplicateKeysFirstWins replaces the JSONAccessor of the Resolver, see WithJSONAccessor.
+func WithDuplicateKeyPolicy(policy DuplicateKeyPolicy) ResolverOption {
+	return func(r *Resolver) {
+		if policy != DuplicateKeysFirstWins {
+			r.json = duplicateKeyAccessor{policy: policy}
+		}
+	}
+}
+
+// duplicateKeyAccessor is a JSONAccessor detecting duplicate keys along the paths it reads
+type duplicateKeyAccessor struct {
+	policy DuplicateKeyPolicy
+}
+
+func (a duplicateKeyAccessor) Get(data []byte, path ...string) (value []byte, dataType jsonparser.ValueType, offset int, err error) {
+	if len(path) == 0 {
+		return jsonparser.Get(data)
+	}
+	value = data
+	for _, key := range path {
+		value, dataType, err = a.get(value, key)
+		if err != nil {
+			return nil, jsonparser.NotExist, -1, err
+		}
+	}
+
+	// values are sub slices of data, so the end offset of the value is derived from the capacities
+	offset = cap(data) - cap(value) + len(value)
+	if dataType == jsonparser.String {
+		offset++ // the closing quote
+	}
+	return value, dataType, offset, nil
+}
+
+// get returns the value of key in object according to the policy
+func (a duplicateKeyAccessor) get(object []byte, key string) (value []byte, dataType jsonparser.ValueType, err error) {
+	if len(key) != 0 && key[0] == '[' {
+		// array indexes can't be duplicated
+		value, dataType, _, err = jsonparser.Get(object, key)
+		return
+	}
+
+	found := 0
+	_ = jsonparser.ObjectEach(object, func(k []byte, v []byte, t jsonparser.ValueType, _ int) error {
+		if !bytes.Equal(k, []byte(key)) {
+			return nil
+		}
+		found++
+		if found == 1 || a.policy == DuplicateKeysLastWins {
+			value, dataType = v, t
+		}
+		return nil
+	})
+
+	switch {
+	case found == 0:
+		// escaped keys aren't matched by the comparison above, so the lookup is left to jsonparser
+		value, dataType, _, err = jsonparser.Get(object, key)
+		return
+	case found > 1 && a.policy == DuplicateKeysError:
+		return nil, jsonparser.NotExist, fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+	}
+	return value, dataType, nil
+}
+
+func (a duplicateKeyAccessor) ArrayEach(data []byte, cb func(value []byte, dataType jsonparser.ValueType, offset int, err error), path ...string) (offset int, err error) {
+	if len(path) != 0 {
+		if data, _, _, err = a.Get(data, path...); err != nil {
+			return -1, err
+		}
+	}
+	return jsonparser.ArrayEach(data, cb)
+}
@@ -1,15 +1,29 @@
 package resolve
 
 import (
+	"context"
 	"hash"
+	"io"
 	"sync"
 
+	"github.com/buger/jsonparser"
 	"github.com/cespare/xxhash/v2"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/fastbuffer"
 	"github.com/wundergraph/graphql-go-tools/pkg/pool"
 )
 
+// Tracer allows to create a span for each fetch without depending on a specific tracing library, e.g. OpenTelemetry.
+// The context returned by StartFetchSpan is passed to DataSource.Load, so the trace context can be propagated to upstreams.
+type Tracer interface {
+	StartFetchSpan(ctx context.Context, identifier []byte, inputSize int) (context.Context, FetchSpan)
+}
+
+type FetchSpan interface {
+	RecordError(err error)
+	End()
+}
+
 type Fetcher struct {
 	EnableSingleFlightLoader bool
 	hash64Pool               sync.Pool
@@ -17,10 +31,49 @@ type Fetcher struct {
 	bufPairPool              sync.Pool
 	inflightFetchMu          *sync.Mutex
 	inflightFetches          map[uint64]*inflightFetch
+	tracer                   Tracer
+	// concurrencyLimit is a semaphore bounding the number of concurrent DataSource.Load calls, nil if unlimited
+	concurrencyLimit chan struct{}
+	// freeing tracks the goroutines returning inflight fetches to the pool once all waiters are done
+	freeing sync.WaitGroup
+}
+
+// FetcherOption configures a Fetcher, the settings of a Fetcher apply to all Resolvers sharing it
+type FetcherOption func(f *Fetcher)
+
+// WithMaxConcurrentFetches limits the number of concurrent DataSource.Load calls across all operations resolved with the Fetcher.
+// Fetches exceeding the limit wait until a running fetch is done. A limit of 0 disables the limit.
+// Resolvers sharing the Fetcher share the limit.
+func WithMaxConcurrentFetches(limit int) FetcherOption {
+	return func(f *Fetcher) {
+		if limit > 0 {
+			f.concurrencyLimit = make(chan struct{}, limit)
+		}
+	}
+}
+
+// WithTracer enables the creation of a span for each fetch
+func WithTracer(tracer Tracer) FetcherOption {
+	return func(f *Fetcher) {
+		f.tracer = tracer
+	}
 }
 
-func NewFetcher(enableSingleFlightLoader bool) *Fetcher {
-	return &Fetcher{
+// WithSingleFlightHash replaces xxhash as hash of the fetch inputs used to deduplicate fetches with the single flight loader,
+// e.g. with a collision resistant hash to prevent engineered collisions sharing the data of another fetch.
+// newHash must return a new hash on each call. SingleFetch.CacheKey takes precedence.
+func WithSingleFlightHash(newHash func() hash.Hash64) FetcherOption {
+	return func(f *Fetcher) {
+		f.hash64Pool = sync.Pool{
+			New: func() interface{} {
+				return newHash()
+			},
+		}
+	}
+}
+
+func NewFetcher(enableSingleFlightLoader bool, options ...FetcherOption) *Fetcher {
+	f := &Fetcher{
 		EnableSingleFlightLoader: enableSingleFlightLoader,
 		hash64Pool: sync.Pool{
 			New: func() interface{} {
@@ -45,18 +98,42 @@ func NewFetcher(enableSingleFlightLoader bool) *Fetcher {
 		inflightFetchMu: &sync.Mutex{},
 		inflightFetches: map[uint64]*inflightFetch{},
 	}
+	for i := range options {
+		options[i](f)
+	}
+	return f
+}
+
+// reset drops all inflight fetches, it must not be called while fetches are in progress
+func (f *Fetcher) reset() {
+	f.inflightFetchMu.Lock()
+	for fetchID := range f.inflightFetches {
+		delete(f.inflightFetches, fetchID)
+	}
+	f.inflightFetchMu.Unlock()
+}
+
+// waitFreed waits until all inflight fetches are returned to the pool, it doesn't drop inflight fetches,
+// so it's safe to call it while other Resolvers sharing the Fetcher are in use
+func (f *Fetcher) waitFreed() {
+	f.freeing.Wait()
 }
 
 func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) (err error) {
 	dataBuf := pool.BytesBuffer.Get()
 	defer pool.BytesBuffer.Put(dataBuf)
 
+	if ctx.fetchDebug != nil {
+		defer ctx.fetchDebug.record(fetch, preparedInput.Bytes(), buf)
+	}
+
 	if ctx.beforeFetchHook != nil {
 		ctx.beforeFetchHook.OnBeforeFetch(f.hookCtx(ctx), preparedInput.Bytes())
 	}
 
-	if !f.EnableSingleFlightLoader || fetch.DisallowSingleFlight {
-		err = fetch.DataSource.Load(ctx.Context, preparedInput.Bytes(), dataBuf)
+	// inputs referencing uploaded files are only equal within a request, so they are never deduplicated
+	if !f.EnableSingleFlightLoader || fetch.DisallowSingleFlight || len(ctx.Files) != 0 {
+		err = f.load(ctx, fetch, preparedInput.Bytes(), dataBuf)
 		extractResponse(dataBuf.Bytes(), buf, fetch.ProcessResponseConfig)
 
 		if ctx.afterFetchHook != nil {
@@ -70,10 +147,7 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 		return
 	}
 
-	hash64 := f.getHash64()
-	_, _ = hash64.Write(preparedInput.Bytes())
-	fetchID := hash64.Sum64()
-	f.putHash64(hash64)
+	fetchID := f.singleFlightKey(fetch, preparedInput.Bytes())
 
 	f.inflightFetchMu.Lock()
 	inflight, ok := f.inflightFetches[fetchID]
@@ -81,7 +155,12 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 		inflight.waitFree.Add(1)
 		defer inflight.waitFree.Done()
 		f.inflightFetchMu.Unlock()
-		inflight.waitLoad.Wait()
+		select {
+		case <-inflight.loaded:
+		case <-ctx.Context.Done():
+			// the leader keeps loading for the other waiters, this waiter doesn't wait beyond its own deadline
+			return ctx.Context.Err()
+		}
 		if inflight.bufPair.HasData() {
 			if ctx.afterFetchHook != nil {
 				ctx.afterFetchHook.OnData(f.hookCtx(ctx), inflight.bufPair.Data.Bytes(), true)
@@ -98,12 +177,11 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 	}
 
 	inflight = f.getInflightFetch()
-	inflight.waitLoad.Add(1)
 	f.inflightFetches[fetchID] = inflight
 
 	f.inflightFetchMu.Unlock()
 
-	err = fetch.DataSource.Load(ctx.Context, preparedInput.Bytes(), dataBuf)
+	err = f.load(ctx, fetch, preparedInput.Bytes(), dataBuf)
 	extractResponse(dataBuf.Bytes(), &inflight.bufPair, fetch.ProcessResponseConfig)
 	inflight.err = err
 
@@ -121,13 +199,15 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 		buf.Errors.WriteBytes(inflight.bufPair.Errors.Bytes())
 	}
 
-	inflight.waitLoad.Done()
+	close(inflight.loaded)
 
 	f.inflightFetchMu.Lock()
 	delete(f.inflightFetches, fetchID)
 	f.inflightFetchMu.Unlock()
 
+	f.freeing.Add(1)
 	go func() {
+		defer f.freeing.Done()
 		inflight.waitFree.Wait()
 		f.freeInflightFetch(inflight)
 	}()
@@ -135,6 +215,80 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 	return
 }
 
+// singleFlightKey hashes the DataSourceIdentifier and the input of a fetch to deduplicate inflight fetches,
+// the identifier is part of the key as DataSources may keep e.g. their URL in their configuration instead of the input.
+// Values at SingleFlightIgnoredInputPaths are removed from the input before hashing, CacheKey replaces the default hash if set.
+func (f *Fetcher) singleFlightKey(fetch *SingleFetch, input []byte) uint64 {
+	if len(fetch.SingleFlightIgnoredInputPaths) != 0 {
+		buf := pool.BytesBuffer.Get()
+		defer pool.BytesBuffer.Put(buf)
+		_, _ = buf.Write(input)
+		input = buf.Bytes()
+		for i := range fetch.SingleFlightIgnoredInputPaths {
+			input = jsonparser.Delete(input, fetch.SingleFlightIgnoredInputPaths[i]...)
+		}
+	}
+
+	if fetch.CacheKey != nil {
+		return fetch.CacheKey(input)
+	}
+
+	hash64 := f.getHash64()
+	_, _ = hash64.Write(singleFlightIdentifier(fetch))
+	// the separator keeps the identifier and the input apart, so no input can be shifted into the identifier
+	_, _ = hash64.Write(singleFlightSeparator)
+	_, _ = hash64.Write(input)
+	fetchID := hash64.Sum64()
+	f.putHash64(hash64)
+	return fetchID
+}
+
+var singleFlightSeparator = []byte{0}
+
+// singleFlightIdentifier returns the DataSourceIdentifier of fetch,
+// fetches created without the planner fall back to the UniqueIdentifier of their DataSource
+func singleFlightIdentifier(fetch *SingleFetch) []byte {
+	if len(fetch.DataSourceIdentifier) != 0 {
+		return fetch.DataSourceIdentifier
+	}
+	if identifier, ok := fetch.DataSource.(UniqueIdentifier); ok {
+		return identifier.UniqueIdentifier()
+	}
+	return nil
+}
+
+func (f *Fetcher) load(ctx *Context, fetch *SingleFetch, input []byte, w io.Writer) (err error) {
+	if f.concurrencyLimit != nil {
+		select {
+		case f.concurrencyLimit <- struct{}{}:
+			defer func() { <-f.concurrencyLimit }()
+		case <-ctx.Context.Done():
+			return ctx.Context.Err()
+		}
+	}
+
+	loadCtx := ctx.Context
+	if ctx.Request.Header != nil {
+		loadCtx = context.WithValue(loadCtx, requestHeaderKey{}, ctx.Request.Header)
+	}
+	if len(ctx.Files) != 0 {
+		loadCtx = ContextWithFiles(loadCtx, ctx.Files)
+	}
+
+	if f.tracer == nil {
+		return fetch.DataSource.Load(loadCtx, input, w)
+	}
+
+	loadCtx, span := f.tracer.StartFetchSpan(loadCtx, fetch.DataSourceIdentifier, len(input))
+	defer span.End()
+
+	err = fetch.DataSource.Load(loadCtx, input, w)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return
+}
+
 func (f *Fetcher) FetchBatch(ctx *Context, fetch *BatchFetch, preparedInputs []*fastbuffer.FastBuffer, bufs []*BufPair) (err error) {
 	inputs := make([][]byte, len(preparedInputs))
 	for i := range preparedInputs {
@@ -170,7 +324,9 @@ func (f *Fetcher) freeBufPair(buf *BufPair) {
 }
 
 func (f *Fetcher) getInflightFetch() *inflightFetch {
-	return f.inflightFetchPool.Get().(*inflightFetch)
+	inflight := f.inflightFetchPool.Get().(*inflightFetch)
+	inflight.loaded = make(chan struct{})
+	return inflight
 }
 
 func (f *Fetcher) freeInflightFetch(inflightFetch *inflightFetch) {
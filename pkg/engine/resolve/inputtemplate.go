@@ -3,6 +3,7 @@ package resolve
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/buger/jsonparser"
 
@@ -42,6 +43,10 @@ func (i *InputTemplate) Render(ctx *Context, data []byte, preparedInput *fastbuf
 				err = i.renderContextVariable(ctx, i.Segments[j], preparedInput)
 			case HeaderVariableKind:
 				err = i.renderHeaderVariable(ctx, i.Segments[j].VariableSourcePath, preparedInput)
+			case IndexVariableKind:
+				i.renderIndexVariable(ctx, preparedInput)
+			case UploadVariableKind:
+				err = i.renderUploadVariable(ctx, i.Segments[j].VariableSourcePath, preparedInput)
 			default:
 				err = fmt.Errorf("InputTemplate.Render: cannot resolve variable of kind: %d", i.Segments[j].VariableKind)
 			}
@@ -109,3 +114,95 @@ func (i *InputTemplate) renderHeaderVariable(ctx *Context, path []string, prepar
 	}
 	return nil
 }
+
+// rendersIndexVariable returns true if the template contains an IndexVariable.
+// Such inputs differ per item of an array, so they can't be rendered by the data loader, which renders the inputs of all items at once.
+func (i *InputTemplate) rendersIndexVariable() bool {
+	for j := range i.Segments {
+		if i.Segments[j].SegmentType == VariableSegmentType && i.Segments[j].VariableKind == IndexVariableKind {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *InputTemplate) renderIndexVariable(ctx *Context, preparedInput *fastbuffer.FastBuffer) {
+	if len(ctx.arrayIndices) == 0 {
+		preparedInput.WriteBytes(literal.NULL)
+		return
+	}
+	preparedInput.WriteString(strconv.Itoa(ctx.arrayIndices[len(ctx.arrayIndices)-1]))
+}
+
+// InputSlotType is the JSON type of a variable slot of an InputTemplateBuilder
+type InputSlotType int
+
+const (
+	InputSlotString InputSlotType = iota + 1
+	InputSlotNumber
+	InputSlotBoolean
+	InputSlotObject
+	InputSlotArray
+)
+
+func (t InputSlotType) jsonSchema() string {
+	switch t {
+	case InputSlotString:
+		return `{"type":"string"}`
+	case InputSlotNumber:
+		return `{"type":"number"}`
+	case InputSlotBoolean:
+		return `{"type":"boolean"}`
+	case InputSlotObject:
+		return `{"type":"object"}`
+	case InputSlotArray:
+		return `{"type":"array"}`
+	default:
+		return `{}`
+	}
+}
+
+// InputTemplateBuilder builds the InputTemplate of a JSON fetch input, e.g. a request body, from static JSON and typed variable slots.
+// Each slot is rendered as JSON value of its type, strings are quoted and escaped, objects and arrays are written as is,
+// so the static JSON must not quote slots. A value of another type fails rendering, a missing value is rendered as null.
+type InputTemplateBuilder struct {
+	segments []TemplateSegment
+}
+
+func NewInputTemplateBuilder() *InputTemplateBuilder {
+	return &InputTemplateBuilder{}
+}
+
+// Static appends static JSON, e.g. {"id":
+func (b *InputTemplateBuilder) Static(data string) *InputTemplateBuilder {
+	b.segments = append(b.segments, TemplateSegment{
+		SegmentType: StaticSegmentType,
+		Data:        []byte(data),
+	})
+	return b
+}
+
+// ContextVariable appends a slot for the variable at path of the operation variables
+func (b *InputTemplateBuilder) ContextVariable(path []string, slotType InputSlotType) *InputTemplateBuilder {
+	return b.variable(ContextVariableKind, path, slotType)
+}
+
+// ObjectVariable appends a slot for the value at path of the enclosing object
+func (b *InputTemplateBuilder) ObjectVariable(path []string, slotType InputSlotType) *InputTemplateBuilder {
+	return b.variable(ObjectVariableKind, path, slotType)
+}
+
+func (b *InputTemplateBuilder) variable(kind VariableKind, path []string, slotType InputSlotType) *InputTemplateBuilder {
+	b.segments = append(b.segments, TemplateSegment{
+		SegmentType:        VariableSegmentType,
+		VariableKind:       kind,
+		VariableSourcePath: path,
+		Renderer:           NewJSONVariableRendererWithValidation(slotType.jsonSchema()),
+	})
+	return b
+}
+
+// Build returns the InputTemplate, the builder must not be used afterwards
+func (b *InputTemplateBuilder) Build() InputTemplate {
+	return InputTemplate{Segments: b.segments}
+}
@@ -0,0 +1,59 @@
+package resolve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/fastbuffer"
+)
+
+func TestInputTemplateBuilder(t *testing.T) {
+	template := NewInputTemplateBuilder().
+		Static(`{"name":`).
+		ContextVariable([]string{"name"}, InputSlotString).
+		Static(`,"limit":`).
+		ContextVariable([]string{"limit"}, InputSlotNumber).
+		Static(`,"filter":`).
+		ContextVariable([]string{"filter"}, InputSlotObject).
+		Static(`,"userId":`).
+		ObjectVariable([]string{"id"}, InputSlotString).
+		Static(`}`).
+		Build()
+
+	render := func(t *testing.T, variables, data string) (string, error) {
+		ctx := NewContext(context.Background())
+		ctx.Variables = []byte(variables)
+		out := fastbuffer.New()
+		err := template.Render(ctx, []byte(data), out)
+		return out.String(), err
+	}
+
+	t.Run("typed slots", func(t *testing.T) {
+		out, err := render(t, `{"name":"Jens \"JS\"","limit":10,"filter":{"tags":["a","b"]}}`, `{"id":"1"}`)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"Jens \"JS\"","limit":10,"filter":{"tags":["a","b"]},"userId":"1"}`, out)
+	})
+
+	t.Run("missing values", func(t *testing.T) {
+		out, err := render(t, `{"name":"Jens"}`, `{}`)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"Jens","limit":null,"filter":null,"userId":null}`, out)
+	})
+
+	t.Run("string into number slot", func(t *testing.T) {
+		_, err := render(t, `{"name":"Jens","limit":"10"}`, `{"id":"1"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("number into string slot", func(t *testing.T) {
+		_, err := render(t, `{"name":1}`, `{"id":"1"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("array into object slot", func(t *testing.T) {
+		_, err := render(t, `{"name":"Jens","filter":[]}`, `{"id":"1"}`)
+		assert.Error(t, err)
+	})
+}
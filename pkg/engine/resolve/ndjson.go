@@ -0,0 +1,38 @@
+package resolve
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// NDJSONToArray converts newline delimited JSON, one JSON value per line, into a JSON array of the values.
+// It can be used as SingleFetch.PostProcess to resolve list fields from backends streaming NDJSON,
+// e.g. {"id":1}\n{"id":2}\n is converted to [{"id":1},{"id":2}]. Empty lines are ignored.
+func NDJSONToArray(data []byte) ([]byte, error) {
+	array := make([]byte, 0, len(data)+2)
+	array = append(array, lBrack...)
+	lineNumber, items := 0, 0
+	for len(data) != 0 {
+		lineNumber++
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i != -1 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, fmt.Errorf("ndjson: invalid JSON in line %d", lineNumber)
+		}
+		if items != 0 {
+			array = append(array, comma...)
+		}
+		array = append(array, line...)
+		items++
+	}
+	return append(array, rBrack...), nil
+}
@@ -0,0 +1,80 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNDJSONToArray(t *testing.T) {
+	t.Run("lines", func(t *testing.T) {
+		array, err := NDJSONToArray([]byte("{\"id\":1}\n{\"id\":2}\r\n\n  {\"id\":3}  \n"))
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"id":1},{"id":2},{"id":3}]`, string(array))
+	})
+
+	t.Run("without trailing newline", func(t *testing.T) {
+		array, err := NDJSONToArray([]byte("1\n\"two\"\nnull"))
+		assert.NoError(t, err)
+		assert.Equal(t, `[1,"two",null]`, string(array))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		array, err := NDJSONToArray([]byte("\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, `[]`, string(array))
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		_, err := NDJSONToArray([]byte("{\"id\":1}\n{\"id\":\n"))
+		assert.EqualError(t, err, "ndjson: invalid JSON in line 2")
+	})
+}
+
+func TestResolver_NDJSONPostProcess(t *testing.T) {
+	rCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := newResolver(rCtx, false, false)
+
+	response := &GraphQLResponse{
+		Data: &Object{
+			Fetch: &SingleFetch{
+				BufferId:    0,
+				DataSource:  FakeDataSource("{\"id\":1,\"title\":\"first\"}\n{\"id\":2,\"title\":\"second\"}\n{\"id\":3,\"title\":\"third\"}\n"),
+				PostProcess: NDJSONToArray,
+			},
+			Fields: []*Field{
+				{
+					Name:      []byte("hits"),
+					HasBuffer: true,
+					BufferID:  0,
+					Value: &Array{
+						Item: &Object{
+							Fields: []*Field{
+								{
+									Name:  []byte("title"),
+									Value: &String{Path: []string{"title"}},
+								},
+							},
+						},
+					},
+				},
+				{
+					Name:      []byte("second"),
+					HasBuffer: true,
+					BufferID:  0,
+					Value: &Integer{
+						Path: []string{"[1]", "id"},
+					},
+				},
+			},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	err := resolver.ResolveGraphQLResponse(NewContext(context.Background()), response, nil, out)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"data":{"hits":[{"title":"first"},{"title":"second"},{"title":"third"}],"second":2}}`, out.String())
+}
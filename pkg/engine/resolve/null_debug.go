@@ -0,0 +1,93 @@
+package resolve
+
+import (
+	"sync"
+
+	"github.com/buger/jsonparser"
+)
+
+// NullReason is the reason why a value resolved to null, see NullExplanation
+type NullReason int
+
+const (
+	// NullReasonNoData means there was no data to resolve the value from, e.g. because the fetch providing it failed
+	NullReasonNoData NullReason = iota + 1
+	// NullReasonMissingKey means the data has no value at the path of the value
+	NullReasonMissingKey
+	// NullReasonExplicitNull means the data has null at the path of the value
+	NullReasonExplicitNull
+	// NullReasonTypeMismatch means the value at the path of the value has a different JSON type than expected, see NullExplanation.ValueType
+	NullReasonTypeMismatch
+)
+
+func (r NullReason) String() string {
+	switch r {
+	case NullReasonNoData:
+		return "NO_DATA"
+	case NullReasonMissingKey:
+		return "MISSING_KEY"
+	case NullReasonExplicitNull:
+		return "EXPLICIT_NULL"
+	case NullReasonTypeMismatch:
+		return "TYPE_MISMATCH"
+	}
+	return "UNKNOWN"
+}
+
+// NullExplanation explains why a scalar or object resolved to null, see Context.EnableNullDebug.
+// A non-nullable value resolving to null nulls its parent, the parent isn't explained separately.
+type NullExplanation struct {
+	// Path is the path of the value in the response, e.g. /data/user/name
+	Path string
+	// ValuePath is the path looked up in the data, e.g. the Path of a String
+	ValuePath []string
+	Reason    NullReason
+	// ValueType is the JSON type of the value found at ValuePath if the Reason is NullReasonTypeMismatch, e.g. number
+	ValueType string
+}
+
+type nullDebugRecorder struct {
+	mu           sync.Mutex
+	explanations []NullExplanation
+}
+
+// EnableNullDebug records an explanation for each value resolved to null with this Context,
+// e.g. to tell a missing key from a type mismatch while integrating a backend.
+// The explanations can be retrieved using NullExplanations after resolving. Nothing is recorded or allocated if it isn't enabled.
+func (c *Context) EnableNullDebug() {
+	c.nullDebug = &nullDebugRecorder{}
+}
+
+// NullExplanations returns a copy of the explanations recorded since EnableNullDebug was called,
+// so it's safe to call while explanations are still being recorded
+func (c *Context) NullExplanations() []NullExplanation {
+	if c.nullDebug == nil {
+		return nil
+	}
+	c.nullDebug.mu.Lock()
+	defer c.nullDebug.mu.Unlock()
+	return append([]NullExplanation(nil), c.nullDebug.explanations...)
+}
+
+// explainNull records why a value resolved to null, it must only be called if null debugging is enabled.
+// data is the data the value was resolved from, valueType and err are the result of looking up valuePath in data.
+func (c *Context) explainNull(data []byte, valuePath []string, valueType jsonparser.ValueType, err error) {
+	explanation := NullExplanation{
+		Path:      string(c.path()),
+		ValuePath: valuePath,
+	}
+	switch {
+	case len(data) == 0:
+		explanation.Reason = NullReasonNoData
+	case err != nil:
+		explanation.Reason = NullReasonMissingKey
+	case valueType == jsonparser.Null:
+		explanation.Reason = NullReasonExplicitNull
+	default:
+		explanation.Reason = NullReasonTypeMismatch
+		explanation.ValueType = valueType.String()
+	}
+	c.nullDebug.mu.Lock()
+	c.nullDebug.explanations = append(c.nullDebug.explanations, explanation)
+	c.nullDebug.mu.Unlock()
+}
@@ -5,15 +5,21 @@ package resolve
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/buger/jsonparser"
 	"github.com/cespare/xxhash/v2"
+	"github.com/jensneuse/abstractlogger"
 	errors "golang.org/x/xerrors"
 
 	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafebytes"
@@ -41,16 +47,38 @@ var (
 	literalPath       = []byte("path")
 	literalExtensions = []byte("extensions")
 
-	unableToResolveMsg = []byte("unable to resolve")
-	emptyArray         = []byte("[]")
+	unableToResolveMsg        = []byte("unable to resolve")
+	nonNullableFieldIsNullMsg = []byte("unable to resolve: origin returned null for non-nullable field")
+	emptyArray                = []byte("[]")
+	forbiddenErrorExtensions  = []byte(`{"code":"FORBIDDEN"}`)
 )
 
 var (
 	errNonNullableFieldValueIsNull = errors.New("non Nullable field value is null")
-	errTypeNameSkipped             = errors.New("skipped because of __typename condition")
-	errHeaderPathInvalid           = errors.New("invalid header path: header variables must be of this format: .request.header.{{ key }} ")
-
-	ErrUnableToResolve = errors.New("unable to resolve operation")
+	// errNonNullableFieldValueIsExplicitNull is returned when the origin explicitly returned null for a non-nullable field
+	// it wraps errNonNullableFieldValueIsNull so that it is propagated just like a missing value
+	errNonNullableFieldValueIsExplicitNull = fmt.Errorf("%w: origin returned null", errNonNullableFieldValueIsNull)
+	errTypeNameSkipped                     = errors.New("skipped because of __typename condition")
+	errHeaderPathInvalid                   = errors.New("invalid header path: header variables must be of this format: .request.header.{{ key }} ")
+
+	// errFieldTimeout is returned when a non-nullable field exceeded its timeout, the timeout error has been added already
+	errFieldTimeout = fmt.Errorf("%w: field timed out", errNonNullableFieldValueIsNull)
+	// errFieldForbidden is returned when a non-nullable field was denied by the FieldAuthorizer, the error has been added already
+	errFieldForbidden = fmt.Errorf("%w: field forbidden", errNonNullableFieldValueIsNull)
+	// errFieldPathCrossesArray is returned when the path of a non-nullable scalar crosses an array, the ErrPathCrossesArray error has been added already
+	errFieldPathCrossesArray = fmt.Errorf("%w: path crosses an array", errNonNullableFieldValueIsNull)
+
+	ErrUnableToResolve      = errors.New("unable to resolve operation")
+	ErrResolverShuttingDown = errors.New("resolver is shutting down")
+	ErrTooManyFetches       = errors.New("maximum number of fetches per operation exceeded")
+	ErrArrayItemPanic       = errors.New("panic while resolving array item")
+	ErrFetchInputTooLarge   = errors.New("fetch input too large")
+	ErrParallelFetchFailed  = errors.New("all parallel fetches failed")
+	ErrMaxDepthExceeded     = errors.New("maximum node depth exceeded")
+	ErrDuplicateKey         = errors.New("duplicate key")
+	ErrPathCrossesArray     = errors.New("path of scalar crosses an array")
+	// ErrInputContainsVariablePlaceholder is returned by ValidateStaticInput
+	ErrInputContainsVariablePlaceholder = errors.New("input contains a variable placeholder")
 )
 
 var (
@@ -65,6 +93,7 @@ var (
 		{"extensions"},
 	}
 	entitiesPath = []string{"_entities"}
+	typeNamePath = []string{"__typename"}
 )
 
 const (
@@ -77,6 +106,9 @@ const (
 	errorsExtensionsPathIndex = 3
 )
 
+// defaultArrayCapacity is the initial capacity of the slices holding the items of lists, see WithArrayCapacity
+const defaultArrayCapacity = 24
+
 type Node interface {
 	NodeKind() NodeKind
 }
@@ -94,10 +126,16 @@ const (
 	NodeKindBoolean
 	NodeKindInteger
 	NodeKindFloat
+	NodeKindStaticValue
+	NodeKindTypeName
+	NodeKindJSONString
+	NodeKindScalarArray
 
 	FetchKindSingle FetchKind = iota + 1
 	FetchKindParallel
 	FetchKindBatch
+	FetchKindTypeConditional
+	FetchKindRace
 )
 
 type HookContext struct {
@@ -113,29 +151,102 @@ type AfterFetchHook interface {
 	OnError(ctx HookContext, output []byte, singleFlight bool)
 }
 
+// RootFieldMiddleware is called once per root field (e.g. a Query or Mutation field) before the field gets resolved.
+// Returning an error denies the field: it resolves to null, the error message is added to the response errors
+// and fetches which only provide data for denied fields are not executed.
+// If the denied field is non-nullable, the null propagates to the data and no fetch is executed at all.
+type RootFieldMiddleware interface {
+	OnRootField(ctx *Context, fieldName []byte) error
+}
+
+// FieldAuthorizer is called for each field of an object before the field gets resolved, e.g. for field level authorization.
+// path is the path of the field in the response, e.g. /data/user/email.
+// Returning an error denies the field: it resolves to null and the error message is added to the response errors
+// with extensions.code FORBIDDEN, so the other fields still resolve. Denied non-nullable fields null their parent.
+type FieldAuthorizer interface {
+	AuthorizeField(ctx *Context, path []byte, field *Field) error
+}
+
 type Context struct {
 	context.Context
-	Variables        []byte
-	Request          Request
-	pathElements     [][]byte
-	responseElements []string
-	lastFetchID      int
-	patches          []patch
-	usedBuffers      []*bytes.Buffer
-	currentPatch     int
-	maxPatch         int
-	pathPrefix       []byte
-	dataLoader       *dataLoader
-	beforeFetchHook  BeforeFetchHook
-	afterFetchHook   AfterFetchHook
-	position         Position
-	RenameTypeNames  []RenameTypeName
+	Variables    []byte
+	Request      Request
+	pathElements [][]byte
+	arrayIndices []int
+	// depth is the number of nodes currently being resolved, it's only tracked if the Resolver has a maximum depth
+	depth               int
+	responseElements    []string
+	lastFetchID         int
+	patches             []patch
+	usedBuffers         []*bytes.Buffer
+	currentPatch        int
+	maxPatch            int
+	pathPrefix          []byte
+	dataLoader          *dataLoader
+	beforeFetchHook     BeforeFetchHook
+	afterFetchHook      AfterFetchHook
+	rootFieldMiddleware RootFieldMiddleware
+	fieldAuthorizer     FieldAuthorizer
+	responseTransform   ResponseTransform
+	heartbeatInterval   time.Duration
+	heartbeatFrame      []byte
+	fetchDebug          *fetchDebugRecorder
+	nullDebug           *nullDebugRecorder
+	cacheControl        *cacheControlRecorder
+	earlyErrors         *earlyErrorWriter
+	responseStream      *responseStream
+	fetchCount          *int64
+	staleFetches        *int32
+	stats               *responseStats
+	tracing             *apolloTracing
+	fetchData           map[int][]byte
+	arrayFetchCache     *arrayFetchCache
+	position            Position
+	RenameTypeNames     []RenameTypeName
+	// Files are the files uploaded with the request, they are referenced by UploadVariables by their index
+	Files []File
 }
 
 type Request struct {
 	Header http.Header
 }
 
+type requestHeaderKey struct{}
+
+// RequestHeaderFromContext returns the header of the Request being resolved, e.g. to forward headers to an upstream.
+// It is available within DataSource.Load and returns nil if the Request has no header.
+func RequestHeaderFromContext(ctx context.Context) http.Header {
+	header, _ := ctx.Value(requestHeaderKey{}).(http.Header)
+	return header
+}
+
+// RequestValueKey identifies a request scoped value, see Context.WithRequestValue.
+// Keys are compared by identity, so values set by different packages never collide, even if their keys have the same name.
+type RequestValueKey struct {
+	name string
+}
+
+// NewRequestValueKey returns a new key, it's meant to be created once, e.g. var TransactionKey = resolve.NewRequestValueKey("transaction")
+func NewRequestValueKey(name string) *RequestValueKey {
+	return &RequestValueKey{name: name}
+}
+
+func (k *RequestValueKey) String() string {
+	return k.name
+}
+
+// WithRequestValue carries a request scoped value, e.g. a database transaction or a request scoped logger,
+// which DataSources retrieve with RequestValue. It must be called after the embedded context.Context is set.
+func (c *Context) WithRequestValue(key *RequestValueKey, value interface{}) {
+	c.Context = context.WithValue(c.Context, key, value)
+}
+
+// RequestValue returns the value set with Context.WithRequestValue for key or nil if it isn't set.
+// It is available within DataSource.Load and SubscriptionDataSource.Start.
+func RequestValue(ctx context.Context, key *RequestValueKey) interface{} {
+	return ctx.Value(key)
+}
+
 func NewContext(ctx context.Context) *Context {
 	return &Context{
 		Context:      ctx,
@@ -173,19 +284,38 @@ func (c *Context) Clone() Context {
 		copy(patches[i].extraPath, c.patches[i].extraPath)
 		copy(patches[i].data, c.patches[i].data)
 	}
+	arrayIndices := make([]int, len(c.arrayIndices))
+	copy(arrayIndices, c.arrayIndices)
 	return Context{
-		Context:         c.Context,
-		Variables:       variables,
-		Request:         c.Request,
-		pathElements:    pathElements,
-		patches:         patches,
-		usedBuffers:     make([]*bytes.Buffer, 0, 48),
-		currentPatch:    c.currentPatch,
-		maxPatch:        c.maxPatch,
-		pathPrefix:      pathPrefix,
-		beforeFetchHook: c.beforeFetchHook,
-		afterFetchHook:  c.afterFetchHook,
-		position:        c.position,
+		Context:             c.Context,
+		Variables:           variables,
+		Request:             c.Request,
+		pathElements:        pathElements,
+		arrayIndices:        arrayIndices,
+		depth:               c.depth,
+		patches:             patches,
+		usedBuffers:         make([]*bytes.Buffer, 0, 48),
+		currentPatch:        c.currentPatch,
+		maxPatch:            c.maxPatch,
+		pathPrefix:          pathPrefix,
+		beforeFetchHook:     c.beforeFetchHook,
+		afterFetchHook:      c.afterFetchHook,
+		rootFieldMiddleware: c.rootFieldMiddleware,
+		fieldAuthorizer:     c.fieldAuthorizer,
+		heartbeatInterval:   c.heartbeatInterval,
+		heartbeatFrame:      c.heartbeatFrame,
+		fetchDebug:          c.fetchDebug,
+		nullDebug:           c.nullDebug,
+		cacheControl:        c.cacheControl,
+		earlyErrors:         c.earlyErrors,
+		responseStream:      c.responseStream,
+		fetchCount:          c.fetchCount,
+		staleFetches:        c.staleFetches,
+		stats:               c.stats,
+		tracing:             c.tracing,
+		fetchData:           c.fetchData,
+		position:            c.position,
+		Files:               c.Files,
 	}
 }
 
@@ -194,6 +324,8 @@ func (c *Context) Free() {
 	c.Variables = c.Variables[:0]
 	c.pathPrefix = c.pathPrefix[:0]
 	c.pathElements = c.pathElements[:0]
+	c.arrayIndices = c.arrayIndices[:0]
+	c.depth = 0
 	c.patches = c.patches[:0]
 	for i := range c.usedBuffers {
 		pool.BytesBuffer.Put(c.usedBuffers[i])
@@ -203,10 +335,36 @@ func (c *Context) Free() {
 	c.maxPatch = -1
 	c.beforeFetchHook = nil
 	c.afterFetchHook = nil
+	c.rootFieldMiddleware = nil
+	c.fieldAuthorizer = nil
+	c.responseTransform = nil
+	c.heartbeatInterval = 0
+	c.heartbeatFrame = nil
+	c.fetchDebug = nil
+	c.nullDebug = nil
+	c.cacheControl = nil
+	c.earlyErrors = nil
+	c.responseStream = nil
+	c.fetchCount = nil
+	c.staleFetches = nil
+	c.stats = nil
+	c.tracing = nil
+	c.fetchData = nil
 	c.Request.Header = nil
 	c.position = Position{}
 	c.dataLoader = nil
 	c.RenameTypeNames = nil
+	c.Files = nil
+}
+
+// writeFetchData writes the data provided to ResolveGraphQLResponseWithData for fetch to buf
+func (c *Context) writeFetchData(fetch *SingleFetch, buf *BufPair) error {
+	data, ok := c.fetchData[fetch.BufferId]
+	if !ok {
+		return fmt.Errorf("no data provided for fetch with buffer id %d", fetch.BufferId)
+	}
+	buf.Data.WriteBytes(data)
+	return nil
 }
 
 func (c *Context) SetBeforeFetchHook(hook BeforeFetchHook) {
@@ -217,6 +375,50 @@ func (c *Context) SetAfterFetchHook(hook AfterFetchHook) {
 	c.afterFetchHook = hook
 }
 
+func (c *Context) SetRootFieldMiddleware(middleware RootFieldMiddleware) {
+	c.rootFieldMiddleware = middleware
+}
+
+func (c *Context) SetFieldAuthorizer(authorizer FieldAuthorizer) {
+	c.fieldAuthorizer = authorizer
+}
+
+// ResponseTransform receives the complete response and returns the response to write instead, e.g. to add extensions.
+// The passed response must not be retained, it may be modified in place and returned.
+type ResponseTransform func(response []byte) ([]byte, error)
+
+// SetResponseTransform transforms the complete response before it is written.
+// The response is buffered, so chunked flushing is disabled.
+func (c *Context) SetResponseTransform(transform ResponseTransform) {
+	c.responseTransform = transform
+}
+
+// SetSubscriptionHeartbeat writes frame through the FlushWriter of a subscription whenever no update was written for interval,
+// e.g. a keep-alive message of the WebSocket protocol, so intermediaries don't close idle connections.
+// frame is written as it is, an interval of 0 disables the heartbeat.
+// If the FlushWriter implements HeartbeatWriter, WriteHeartbeat is called instead, e.g. to write an SSE comment instead of an update.
+func (c *Context) SetSubscriptionHeartbeat(interval time.Duration, frame []byte) {
+	c.heartbeatInterval = interval
+	c.heartbeatFrame = frame
+}
+
+// EnableFetchDebug records the input and the raw response of each fetch executed with this Context.
+// The recorded entries can be retrieved using FetchDebugEntries after resolving.
+func (c *Context) EnableFetchDebug() {
+	c.fetchDebug = &fetchDebugRecorder{}
+}
+
+// FetchDebugEntries returns a copy of the fetches recorded since EnableFetchDebug was called,
+// so it's safe to call while fetches are still being recorded
+func (c *Context) FetchDebugEntries() []FetchDebugEntry {
+	if c.fetchDebug == nil {
+		return nil
+	}
+	c.fetchDebug.mu.Lock()
+	defer c.fetchDebug.mu.Unlock()
+	return append([]FetchDebugEntry(nil), c.fetchDebug.entries...)
+}
+
 func (c *Context) setPosition(position Position) {
 	c.position = position
 }
@@ -287,6 +489,159 @@ func (c *Context) popNextPatch() (patch patch, ok bool) {
 	return c.patches[c.currentPatch], true
 }
 
+type FetchDebugEntry struct {
+	Identifier []byte
+	Input      []byte
+	Data       []byte
+	Errors     []byte
+}
+
+type fetchDebugRecorder struct {
+	mu      sync.Mutex
+	entries []FetchDebugEntry
+}
+
+func (f *fetchDebugRecorder) record(fetch *SingleFetch, input []byte, buf *BufPair) {
+	entry := FetchDebugEntry{
+		Identifier: fetch.DataSourceIdentifier,
+		Input:      append([]byte(nil), input...),
+		Data:       append([]byte(nil), buf.Data.Bytes()...),
+		Errors:     append([]byte(nil), buf.Errors.Bytes()...),
+	}
+	f.mu.Lock()
+	f.entries = append(f.entries, entry)
+	f.mu.Unlock()
+}
+
+// earlyErrorWriter flushes errors as a message {"errors":[...]} as soon as they occur, see ResolveGraphQLResponseWithEarlyErrors
+type earlyErrorWriter struct {
+	mu       sync.Mutex
+	writer   FlushWriter
+	redactor ErrorRedactor
+	err      error
+}
+
+// write flushes errs, the comma separated JSON objects of one or more errors
+func (e *earlyErrorWriter) write(errs []byte) {
+	if len(errs) == 0 {
+		return
+	}
+	if e.redactor != nil {
+		errs = redactErrors(e.redactor, errs)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.err = writeSafe(e.err, e.writer, lBrace)
+	e.err = writeSafe(e.err, e.writer, quote)
+	e.err = writeSafe(e.err, e.writer, literalErrors)
+	e.err = writeSafe(e.err, e.writer, quote)
+	e.err = writeSafe(e.err, e.writer, colon)
+	e.err = writeSafe(e.err, e.writer, lBrack)
+	e.err = writeSafe(e.err, e.writer, errs)
+	e.err = writeSafe(e.err, e.writer, rBrack)
+	e.err = writeSafe(e.err, e.writer, rBrace)
+	if e.err == nil {
+		e.writer.Flush()
+	}
+}
+
+// responseStream writes the data of the root object while it's resolved, see WithResponseFlushThreshold.
+// buf is the BufPair of the root object, its data is written and reset whenever a top-level field completes the threshold.
+type responseStream struct {
+	writer    io.Writer
+	flush     func()
+	threshold int
+	buf       *BufPair
+	// started is true once the beginning of the response has been written
+	started bool
+	// written is the number of bytes written so far
+	written int
+}
+
+// fieldResolved flushes the data of the root object resolved so far once it reaches the threshold
+func (s *responseStream) fieldResolved() error {
+	if s.buf.Data.Len() < s.threshold {
+		return nil
+	}
+	var err error
+	if !s.started {
+		err = s.write(err, lBrace)
+		err = s.write(err, quote)
+		err = s.write(err, literalData)
+		err = s.write(err, quote)
+		err = s.write(err, colon)
+		s.started = true
+	}
+	err = s.write(err, s.buf.Data.Bytes())
+	if err != nil {
+		return err
+	}
+	s.buf.Data.Reset()
+	s.flush()
+	return nil
+}
+
+func (s *responseStream) write(err error, data []byte) error {
+	if err != nil {
+		return err
+	}
+	n, err := s.writer.Write(data)
+	s.written += n
+	return err
+}
+
+// streamable returns true if root may be written while it's resolved.
+// This is the case if all top-level fields are nullable, so no error can null the data which has been written already.
+func streamable(root Node) bool {
+	object, ok := root.(*Object)
+	if !ok || len(object.Fields) == 0 {
+		return false
+	}
+	for _, field := range object.Fields {
+		if !nodeNullable(field.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// CacheControl describes whether a resolved response may be cached as a whole, e.g. by an HTTP caching middleware
+type CacheControl struct {
+	// Cacheable is false if the response contains a field marked with NoCache
+	Cacheable bool
+	// MaxAge is the smallest SingleFetch.MaxAge of all executed fetches, 0 if no fetch provided a hint
+	MaxAge time.Duration
+}
+
+type cacheControlRecorder struct {
+	mu      sync.Mutex
+	noCache bool
+	maxAge  time.Duration
+}
+
+func (c *cacheControlRecorder) recordNoCache() {
+	c.mu.Lock()
+	c.noCache = true
+	c.mu.Unlock()
+}
+
+func (c *cacheControlRecorder) recordMaxAge(maxAge time.Duration) {
+	c.mu.Lock()
+	if c.maxAge == 0 || maxAge < c.maxAge {
+		c.maxAge = maxAge
+	}
+	c.mu.Unlock()
+}
+
+func (c *cacheControlRecorder) cacheControl() CacheControl {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheControl{
+		Cacheable: !c.noCache,
+		MaxAge:    c.maxAge,
+	}
+}
+
 type patch struct {
 	path, extraPath, data []byte
 	index                 int
@@ -311,12 +666,52 @@ type DataSource interface {
 	Load(ctx context.Context, input []byte, w io.Writer) (err error)
 }
 
+// UniqueIdentifier can be implemented by a DataSource to set the DataSourceIdentifier of its fetches,
+// which otherwise defaults to the type name of the DataSource.
+type UniqueIdentifier interface {
+	UniqueIdentifier() []byte
+}
+
+// SubscriptionDataSource sends the updates of a subscription to next until ctx is done.
+// Errors occurring after Start returned are reported with ReportSubscriptionError.
 type SubscriptionDataSource interface {
 	Start(ctx context.Context, input []byte, next chan<- []byte) error
 }
 
+// JSONAccessor extracts values from the JSON data of fetches while resolving objects, arrays, scalars and type names,
+// including the type names of Field.OnTypeName conditions and TypeConditionalFetch.
+// It defaults to an implementation based on github.com/buger/jsonparser and allows to swap the parser, e.g. for benchmarking.
+// Implementations must follow the semantics of jsonparser.Get and jsonparser.ArrayEach.
+type JSONAccessor interface {
+	Get(data []byte, path ...string) (value []byte, dataType jsonparser.ValueType, offset int, err error)
+	ArrayEach(data []byte, cb func(value []byte, dataType jsonparser.ValueType, offset int, err error), path ...string) (offset int, err error)
+}
+
+type jsonparserAccessor struct{}
+
+func (jsonparserAccessor) Get(data []byte, path ...string) (value []byte, dataType jsonparser.ValueType, offset int, err error) {
+	return jsonparser.Get(data, path...)
+}
+
+func (jsonparserAccessor) ArrayEach(data []byte, cb func(value []byte, dataType jsonparser.ValueType, offset int, err error), path ...string) (offset int, err error) {
+	return jsonparser.ArrayEach(data, cb, path...)
+}
+
+// Metrics is an optional sink for observability, e.g. to export Prometheus metrics.
+// Implementations must be safe for concurrent use as fetches are executed concurrently.
+type Metrics interface {
+	// IncFetch is called for each executed fetch with the DataSourceIdentifier of the fetch
+	IncFetch(identifier []byte)
+	// IncFetchError is called for each fetch which returned an error or a response containing errors
+	IncFetchError(identifier []byte)
+	// ObserveResponseBytes is called with the number of bytes written for each response
+	ObserveResponseBytes(n int)
+}
+
 type Resolver struct {
-	ctx               context.Context
+	ctx context.Context
+	// cancel cancels ctx, it ends all active subscriptions of the Resolver, see Close
+	cancel            context.CancelFunc
 	dataLoaderEnabled bool
 	resultSetPool     sync.Pool
 	byteSlicesPool    sync.Pool
@@ -325,34 +720,276 @@ type Resolver struct {
 	bufPairSlicePool  sync.Pool
 	errChanPool       sync.Pool
 	hash64Pool        sync.Pool
+	arrayFetchCaches  sync.Pool
 	dataloaderFactory *dataLoaderFactory
 	fetcher           *Fetcher
+	json              JSONAccessor
+	metrics           Metrics
+	shutdownMu        sync.RWMutex
+	shuttingDown      bool
+	activeFetches     sync.WaitGroup
+	// fetchesDone is closed once all running fetches are done after Shutdown, a single goroutine waits for it, see Shutdown
+	fetchesDone     chan struct{}
+	waitFetchesOnce sync.Once
+	// background tracks the goroutines resolving responses asynchronously, see ResolveGraphQLResponseAsync,
+	// and the active subscriptions, see ResolveGraphQLSubscription
+	background sync.WaitGroup
+	// responseFlushThreshold is the minimum number of bytes between two flushes of a chunked response, 0 disables chunking
+	responseFlushThreshold int
+	fetchErrorMode         FetchErrorMode
+	prettyPrint            bool
+	log                    abstractlogger.Logger
+	redactFetchInput       bool
+	maxFetchesPerOperation int64
+	validateIntRange       bool
+	sortKeys               bool
+	maxDepth               int
+	responseEnvelope       ResponseEnvelope
+	staleFetches           *staleFetchCache
+	errorRedactor          ErrorRedactor
+	responseStats          bool
+	apolloTracing          bool
+	fieldTimeout           time.Duration
+	arrayCapacity          int
+	adaptiveArrayCapacity  bool
+}
+
+// FetchErrorMode defines how the Resolver handles fetches failing with an error or returning GraphQL errors
+type FetchErrorMode int
+
+const (
+	// ErrorModeNull records the errors of a failed fetch and resolves the affected fields to null, as defined by the GraphQL specification
+	ErrorModeNull FetchErrorMode = iota
+	// ErrorModeFailFast aborts the whole response with the errors of the first failed fetch, the response contains no data
+	ErrorModeFailFast
+)
+
+// fetchFailedError aborts resolving in ErrorModeFailFast, errors contains the comma separated GraphQL errors of the failed fetch
+type fetchFailedError struct {
+	errors []byte
+}
+
+func (e *fetchFailedError) Error() string {
+	return "fetch failed: " + string(e.errors)
+}
+
+// FetchError is the error of a failed fetch with a ServiceName, it's returned if the error isn't written to the response,
+// e.g. by a fetch which isn't part of a ParallelFetch. errors.As gives access to the service name of the failed fetch.
+type FetchError struct {
+	ServiceName string
+	Err         error
+}
+
+func (e *FetchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
 }
 
 type inflightFetch struct {
-	waitLoad sync.WaitGroup
+	// loaded is closed by the leader once the data is loaded, it's a channel so waiters can give up on their context
+	loaded   chan struct{}
 	waitFree sync.WaitGroup
 	err      error
 	bufPair  BufPair
 }
 
-// New returns a new Resolver, ctx.Done() is used to cancel all active subscriptions & streams
-func New(ctx context.Context, fetcher *Fetcher, enableDataLoader bool) *Resolver {
-	return &Resolver{
-		ctx: ctx,
+// ResolverOption configures a Resolver on creation
+type ResolverOption func(r *Resolver)
+
+// WithResponseFlushThreshold enables chunked query responses for writers implementing FlushWriter.
+// The data is written while it's resolved: as soon as a top-level field of "data" is resolved
+// and at least threshold bytes of data are pending, they're written and the writer is flushed,
+// so each flushed chunk ends at a field boundary of the root object.
+// In a chunked response the errors follow the data, the final chunk (closing the response) is left to be flushed by the caller.
+// Responses are only chunked if all top-level fields are nullable, as an error in a non-nullable one nulls the data written already.
+// Neither are responses with ErrorModeFailFast, a ResponseTransform, WithResponseEnvelope or WithPrettyPrint, these are written as a whole.
+// If resolving fails after the first flush, e.g. because ctx is cancelled, the written response is incomplete.
+// Subscriptions and streaming responses are never chunked as each flush emits a complete message there.
+// The Flush implementation of the writer must pass the data on instead of discarding it.
+func WithResponseFlushThreshold(threshold int) ResolverOption {
+	return func(r *Resolver) {
+		r.responseFlushThreshold = threshold
+	}
+}
+
+// WithMetrics enables reporting to the metrics sink
+func WithMetrics(metrics Metrics) ResolverOption {
+	return func(r *Resolver) {
+		r.metrics = metrics
+	}
+}
+
+// WithLogger logs failed fetches, including the DataSourceIdentifier, the input and the error,
+// as well as non-nullable fields resolving to null. Logging is disabled if log is nil.
+func WithLogger(log abstractlogger.Logger) ResolverOption {
+	return func(r *Resolver) {
+		r.log = log
+	}
+}
+
+// WithRedactedFetchInput omits the input of failed fetches from the logs, e.g. because it contains credentials
+func WithRedactedFetchInput() ResolverOption {
+	return func(r *Resolver) {
+		r.redactFetchInput = true
+	}
+}
+
+// WithPrettyPrint writes responses indented by two spaces, e.g. for debugging.
+// It should not be used in production as the response is indented after resolving, which disables chunked flushing.
+func WithPrettyPrint() ResolverOption {
+	return func(r *Resolver) {
+		r.prettyPrint = true
+	}
+}
+
+// WithResponseEnvelope replaces the top-level framing of query responses, e.g. DataOnlyResponseEnvelope for REST-style clients.
+// Responses are buffered to be passed to the envelope, which disables chunked flushing.
+// The default is the standard GraphQL response, see StandardResponseEnvelope.
+func WithResponseEnvelope(envelope ResponseEnvelope) ResolverOption {
+	return func(r *Resolver) {
+		r.responseEnvelope = envelope
+	}
+}
+
+// WithStaleOnError serves the data of the last successful fetch with the same DataSourceIdentifier and input
+// if a DataSource fails with an error, e.g. to stay available while a backend is down.
+// Responses containing stale data have the extension "stale":true.
+// The data of up to maxEntries fetches is kept in memory, GraphQL errors returned by a DataSource are not replaced.
+func WithStaleOnError(maxEntries int) ResolverOption {
+	return func(r *Resolver) {
+		if maxEntries > 0 {
+			r.staleFetches = newStaleFetchCache(maxEntries)
+		}
+	}
+}
+
+// ErrorRedactor returns the message to write instead of message, e.g. to hide internal details of downstream errors.
+// Both messages are the content of JSON strings, so they are escaped.
+type ErrorRedactor func(message []byte) []byte
+
+// WithErrorRedactor applies redactor to the message of every error of a response before it is written,
+// including the errors returned by DataSources and the early errors of ResolveGraphQLResponseWithEarlyErrors.
+// The errors of deferred and streamed patches are not redacted.
+func WithErrorRedactor(redactor ErrorRedactor) ResolverOption {
+	return func(r *Resolver) {
+		r.errorRedactor = redactor
+	}
+}
+
+// WithResponseStats adds a summary of the work done to resolve each response to its extensions, e.g. for client-side dashboards:
+// "stats":{"bytes":1024,"fields":42,"fetches":3,"fetchTimeMs":12.345}
+// bytes is the size of the data and errors, fetchTimeMs is the sum of the durations of all fetches,
+// so it exceeds the wall time if fetches run in parallel. Fetches served from a cache aren't counted.
+func WithResponseStats() ResolverOption {
+	return func(r *Resolver) {
+		r.responseStats = true
+	}
+}
+
+// WithApolloFetchTracing adds the timings of the fetches of each response to its extensions in the Apollo Tracing format version 1:
+// "tracing":{"version":1,"startTime":"...","endTime":"...","duration":123,"execution":{"resolvers":[...]}}
+// Unlike full Apollo Tracing, only fetches are listed as resolvers, with the path of the field they were started at,
+// fields resolved from the data of a fetch aren't listed. parentType and returnType are always empty as the plan doesn't carry the types.
+// Durations and offsets are in nanoseconds, fetches served from a cache aren't listed.
+func WithApolloFetchTracing() ResolverOption {
+	return func(r *Resolver) {
+		r.apolloTracing = true
+	}
+}
+
+// WithFieldTimeout limits the time to resolve each field with an object or list value, including its nested fetches and fields.
+// A field exceeding the timeout resolves to null with an error, non-nullable fields null their parent.
+// Field.Timeout overrides the timeout for a single field, including scalar fields.
+func WithFieldTimeout(timeout time.Duration) ResolverOption {
+	return func(r *Resolver) {
+		r.fieldTimeout = timeout
+	}
+}
+
+// WithArrayCapacity sets the initial capacity of the slices holding the items of a list while it's resolved, it defaults to 24.
+// Workloads dominated by large lists benefit from a larger capacity, as the slices don't have to grow.
+func WithArrayCapacity(capacity int) ResolverOption {
+	return func(r *Resolver) {
+		if capacity > 0 {
+			r.arrayCapacity = capacity
+		}
+	}
+}
+
+// WithAdaptiveArrayCapacity sizes the slice holding the items of a list by the number of items the same Array had the last time,
+// so lists of varying sizes don't cause repeated growth of the pooled slices.
+func WithAdaptiveArrayCapacity() ResolverOption {
+	return func(r *Resolver) {
+		r.adaptiveArrayCapacity = true
+	}
+}
+
+// WithJSONAccessor replaces the JSONAccessor used to extract values from the data of fetches
+func WithJSONAccessor(accessor JSONAccessor) ResolverOption {
+	return func(r *Resolver) {
+		r.json = accessor
+	}
+}
+
+// WithFetchErrorMode sets how failed fetches are handled, it defaults to ErrorModeNull
+func WithFetchErrorMode(mode FetchErrorMode) ResolverOption {
+	return func(r *Resolver) {
+		r.fetchErrorMode = mode
+	}
+}
+
+// WithSortedKeys writes the fields of each object sorted by name instead of the order of the selection set,
+// e.g. for stable cache keys or snapshot tests.
+// Objects of a plan which isn't sorted already are sorted on each resolve,
+// sort the plan once using postprocess.ProcessSortFields to avoid that.
+func WithSortedKeys() ResolverOption {
+	return func(r *Resolver) {
+		r.sortKeys = true
+	}
+}
+
+// WithIntRangeValidation treats values of Integer nodes which are not a signed 32-bit integer, as mandated for the Int scalar,
+// as type mismatch, so they resolve to null or fail if the field is non-nullable.
+func WithIntRangeValidation() ResolverOption {
+	return func(r *Resolver) {
+		r.validateIntRange = true
+	}
+}
+
+// WithMaxDepth aborts resolving with ErrMaxDepthExceeded once more than limit nodes are nested,
+// e.g. to guard against cyclic node trees of dynamically built plans. A limit of 0 disables the limit.
+func WithMaxDepth(limit int) ResolverOption {
+	return func(r *Resolver) {
+		r.maxDepth = limit
+	}
+}
+
+// WithMaxFetchesPerOperation aborts resolving an operation with ErrTooManyFetches once it executes more than limit fetches,
+// e.g. because of nested lists fanning out to a fetch per item. A limit of 0 disables the limit.
+func WithMaxFetchesPerOperation(limit int) ResolverOption {
+	return func(r *Resolver) {
+		r.maxFetchesPerOperation = int64(limit)
+	}
+}
+
+// New returns a new Resolver, ctx.Done() is used to cancel all active subscriptions, just like Close.
+// The fetcher may be shared by several Resolvers.
+func New(ctx context.Context, fetcher *Fetcher, enableDataLoader bool, options ...ResolverOption) *Resolver {
+	resolverCtx, cancel := context.WithCancel(ctx)
+	resolver := &Resolver{
+		ctx:    resolverCtx,
+		cancel: cancel,
 		resultSetPool: sync.Pool{
 			New: func() interface{} {
 				return &resultSet{
-					buffers: make(map[int]*BufPair, 8),
+					buffers:   make(map[int]*BufPair, 8),
+					bufferIDs: make([]int, 0, 8),
 				}
 			},
 		},
-		byteSlicesPool: sync.Pool{
-			New: func() interface{} {
-				slice := make([][]byte, 0, 24)
-				return &slice
-			},
-		},
 		waitGroupPool: sync.Pool{
 			New: func() interface{} {
 				return &sync.WaitGroup{}
@@ -383,18 +1020,148 @@ func New(ctx context.Context, fetcher *Fetcher, enableDataLoader bool) *Resolver
 				return xxhash.New()
 			},
 		},
+		arrayFetchCaches: sync.Pool{
+			New: func() interface{} {
+				return &arrayFetchCache{
+					entries: map[arrayFetchKey]*arrayFetchResult{},
+				}
+			},
+		},
 		dataloaderFactory: newDataloaderFactory(fetcher),
 		fetcher:           fetcher,
+		json:              jsonparserAccessor{},
+		fetchesDone:       make(chan struct{}),
 		dataLoaderEnabled: enableDataLoader,
+		arrayCapacity:     defaultArrayCapacity,
+	}
+	for _, option := range options {
+		option(resolver)
+	}
+	resolver.byteSlicesPool.New = func() interface{} {
+		slice := make([][]byte, 0, resolver.arrayCapacity)
+		return &slice
+	}
+	return resolver
+}
+
+// Shutdown stops the Resolver from starting new fetches and waits until all running fetches are done or ctx is done.
+// Fetches started after Shutdown was called fail with ErrResolverShuttingDown.
+func (r *Resolver) Shutdown(ctx context.Context) error {
+	r.shutdownMu.Lock()
+	r.shuttingDown = true
+	r.shutdownMu.Unlock()
+
+	// repeated calls, e.g. retries after a timeout, share the goroutine waiting for the fetches
+	r.waitFetchesOnce.Do(func() {
+		go func() {
+			r.activeFetches.Wait()
+			close(r.fetchesDone)
+		}()
+	})
+
+	select {
+	case <-r.fetchesDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close shuts the Resolver down, ends all active subscriptions and waits until all running fetches, asynchronous responses
+// and subscriptions are done, e.g. before a Resolver is discarded on a schema reload.
+// Close waits without a deadline, Shutdown can be called before to bound the time waiting for fetches.
+// The inflight fetches of the Fetcher are left untouched, as it may be shared with other Resolvers.
+// The Resolver must not be used after Close, its pools are released with the Resolver.
+func (r *Resolver) Close() error {
+	if err := r.Shutdown(context.Background()); err != nil {
+		return err
+	}
+	r.cancel()
+	r.background.Wait()
+	r.fetcher.waitFreed()
+	return nil
+}
+
+// startBackground registers a goroutine or subscription which must be awaited by Close,
+// it fails with ErrResolverShuttingDown once Shutdown was called, so nothing is registered while Close is waiting
+func (r *Resolver) startBackground() error {
+	r.shutdownMu.RLock()
+	defer r.shutdownMu.RUnlock()
+	if r.shuttingDown {
+		return ErrResolverShuttingDown
+	}
+	r.background.Add(1)
+	return nil
+}
+
+// Reset brings the Resolver back to a clean baseline so that it can be reused across benchmark iterations.
+// Inflight fetches are dropped while the pools are kept, pooled objects are already reset when they are returned.
+// Reset is not safe for concurrent use: it must only be called while no resolution is in progress.
+func (r *Resolver) Reset() {
+	r.fetcher.reset()
+}
+
+// countFetch fails with ErrTooManyFetches once the operation exceeds the maximum number of fetches
+func (r *Resolver) countFetch(ctx *Context) error {
+	if ctx.fetchCount == nil {
+		return nil
+	}
+	if atomic.AddInt64(ctx.fetchCount, 1) > r.maxFetchesPerOperation {
+		return fmt.Errorf("%w: limit is %d", ErrTooManyFetches, r.maxFetchesPerOperation)
+	}
+	return nil
+}
+
+func (r *Resolver) startFetch() error {
+	r.shutdownMu.RLock()
+	defer r.shutdownMu.RUnlock()
+	if r.shuttingDown {
+		return ErrResolverShuttingDown
 	}
+	r.activeFetches.Add(1)
+	return nil
+}
+
+// SetResponseFlushThreshold enables chunked query responses, it must be called before the Resolver is used.
+//
+// Deprecated: Use WithResponseFlushThreshold instead.
+func (r *Resolver) SetResponseFlushThreshold(threshold int) {
+	r.responseFlushThreshold = threshold
+}
+
+// SetTracer enables the creation of a span for each fetch, it must be called before the Fetcher of the Resolver is used.
+// The tracer is set on the Fetcher, so it applies to all Resolvers sharing the Fetcher.
+//
+// Deprecated: Use WithTracer with NewFetcher instead.
+func (r *Resolver) SetTracer(tracer Tracer) {
+	r.fetcher.tracer = tracer
+}
+
+// SetMetrics enables reporting to the metrics sink, it must be called before the Resolver is used.
+//
+// Deprecated: Use WithMetrics instead.
+func (r *Resolver) SetMetrics(metrics Metrics) {
+	r.metrics = metrics
 }
 
 func (r *Resolver) resolveNode(ctx *Context, node Node, data []byte, bufPair *BufPair) (err error) {
+	if r.maxDepth > 0 {
+		ctx.depth++
+		defer func() {
+			ctx.depth--
+		}()
+		if ctx.depth > r.maxDepth {
+			return fmt.Errorf("%w: limit is %d", ErrMaxDepthExceeded, r.maxDepth)
+		}
+	}
+
 	switch n := node.(type) {
 	case *Object:
 		return r.resolveObject(ctx, n, data, bufPair)
 	case *Array:
 		return r.resolveArray(ctx, n, data, bufPair)
+	case *ScalarArray:
+		return r.resolveScalarArray(n, data, bufPair)
 	case *Null:
 		if n.Defer.Enabled {
 			r.preparePatch(ctx, n.Defer.PatchIndex, nil, data)
@@ -402,12 +1169,32 @@ func (r *Resolver) resolveNode(ctx *Context, node Node, data []byte, bufPair *Bu
 		r.resolveNull(bufPair.Data)
 		return
 	case *String:
+		if len(n.Transforms) != 0 {
+			return r.resolveTransformed(n.Transforms, bufPair, func(buf *BufPair) error {
+				return r.resolveString(ctx, n, data, buf)
+			})
+		}
 		return r.resolveString(ctx, n, data, bufPair)
 	case *Boolean:
+		if len(n.Transforms) != 0 {
+			return r.resolveTransformed(n.Transforms, bufPair, func(buf *BufPair) error {
+				return r.resolveBoolean(ctx, n, data, buf)
+			})
+		}
 		return r.resolveBoolean(ctx, n, data, bufPair)
 	case *Integer:
+		if len(n.Transforms) != 0 {
+			return r.resolveTransformed(n.Transforms, bufPair, func(buf *BufPair) error {
+				return r.resolveInteger(ctx, n, data, buf)
+			})
+		}
 		return r.resolveInteger(ctx, n, data, bufPair)
 	case *Float:
+		if len(n.Transforms) != 0 {
+			return r.resolveTransformed(n.Transforms, bufPair, func(buf *BufPair) error {
+				return r.resolveFloat(ctx, n, data, buf)
+			})
+		}
 		return r.resolveFloat(ctx, n, data, bufPair)
 	case *EmptyObject:
 		r.resolveEmptyObject(bufPair.Data)
@@ -415,6 +1202,13 @@ func (r *Resolver) resolveNode(ctx *Context, node Node, data []byte, bufPair *Bu
 	case *EmptyArray:
 		r.resolveEmptyArray(bufPair.Data)
 		return
+	case *StaticValue:
+		bufPair.Data.WriteBytes(n.Value)
+		return
+	case *TypeName:
+		return r.resolveTypeName(ctx, n, data, bufPair)
+	case *JSONString:
+		return r.resolveJSONString(n, data, bufPair)
 	default:
 		return
 	}
@@ -471,32 +1265,189 @@ func extractResponse(responseData []byte, bufPair *BufPair, cfg ProcessResponseC
 	}, responsePaths...)
 }
 
+// ResolveGraphQLResponse resolves the response and writes it to writer.
+// data is optional and seeds the resolution of response.Data, it's usually nil as the data is loaded by fetches.
+// If provided, data must be a GraphQL response document, e.g. {"data":{...},"errors":[...]}.
+// Its "data" object is used as the root data, so a fully provided document can be resolved without any fetch,
+// its "errors" are added to the errors of the response.
+// If a flush threshold is set using WithResponseFlushThreshold and writer implements FlushWriter,
+// the response is written in chunks, see WithResponseFlushThreshold for the flush boundaries.
 func (r *Resolver) ResolveGraphQLResponse(ctx *Context, response *GraphQLResponse, data []byte, writer io.Writer) (err error) {
+	var flush func()
+	if flushWriter, ok := writer.(FlushWriter); ok && r.responseFlushThreshold > 0 {
+		flush = flushWriter.Flush
+	}
+	return r.resolveGraphQLResponse(ctx, response, data, writer, flush)
+}
 
-	buf := r.getBufPair()
-	defer r.freeBufPair(buf)
-
-	responseBuf := r.getBufPair()
-	defer r.freeBufPair(responseBuf)
+// ResolveGraphQLResponseWithData resolves the response from previously captured fetch results instead of loading them,
+// e.g. to re-shape a cached response. fetchData maps the BufferId of each fetch to its data,
+// which is the extracted data as written by the Fetcher, see FetchDebugEntry.Data.
+// No DataSource is invoked, SingleFetch.PostProcess is applied to the provided data. Fetches sharing a BufferId, e.g. nested in arrays, all resolve to the same data.
+// A fetch without entry in fetchData fails with an error.
+func (r *Resolver) ResolveGraphQLResponseWithData(ctx *Context, response *GraphQLResponse, fetchData map[int][]byte, writer io.Writer) (err error) {
+	ctx.fetchData = fetchData
+	defer func() {
+		ctx.fetchData = nil
+	}()
+	return r.ResolveGraphQLResponse(ctx, response, nil, writer)
+}
 
-	extractResponse(data, responseBuf, ProcessResponseConfig{ExtractGraphqlResponse: true})
+// ResolveGraphQLResponseWithCacheControl resolves the response like ResolveGraphQLResponse
+// and additionally reports whether the response may be cached and for how long.
+func (r *Resolver) ResolveGraphQLResponseWithCacheControl(ctx *Context, response *GraphQLResponse, data []byte, writer io.Writer) (cacheControl CacheControl, err error) {
+	recorder := &cacheControlRecorder{}
+	ctx.cacheControl = recorder
+	defer func() {
+		ctx.cacheControl = nil
+	}()
+	if err = r.ResolveGraphQLResponse(ctx, response, data, writer); err != nil {
+		return cacheControl, err
+	}
+	return recorder.cacheControl(), nil
+}
 
-	if data != nil {
-		ctx.lastFetchID = initialValueID
+// ResolveGraphQLResponseWithEarlyErrors resolves the response like ResolveGraphQLResponse,
+// but flushes the errors of fetches and fields as soon as they occur, e.g. for dashboards interested in early failure signals.
+// Each early error is written as a message {"errors":[...]} followed by a flush,
+// the complete response follows afterwards and contains all errors again. Flushing the complete response is left to the caller.
+func (r *Resolver) ResolveGraphQLResponseWithEarlyErrors(ctx *Context, response *GraphQLResponse, data []byte, writer FlushWriter) (err error) {
+	earlyErrors := &earlyErrorWriter{writer: writer, redactor: r.errorRedactor}
+	ctx.earlyErrors = earlyErrors
+	defer func() {
+		ctx.earlyErrors = nil
+	}()
+	if err = r.resolveGraphQLResponse(ctx, response, data, writer, nil); err != nil {
+		return err
 	}
+	return earlyErrors.err
+}
 
-	if r.dataLoaderEnabled {
-		ctx.dataLoader = r.dataloaderFactory.newDataLoader(responseBuf.Data.Bytes())
-		defer func() {
+// ResolveGraphQLResponseAsync resolves the response like ResolveGraphQLResponse in a new goroutine
+// and sends the output as chunks instead of writing it to an io.Writer, e.g. to pipe it into a custom transport.
+// Chunks end at the flush boundaries described at WithResponseFlushThreshold,
+// without a flush threshold the whole response is sent as a single chunk.
+// The chunk channel is closed once the response is resolved, afterwards the error channel receives the result and is closed.
+// If ctx is cancelled before all chunks are received, the remaining chunks are dropped.
+// ctx must not be freed or reused before the error channel is closed.
+// Once the Resolver is closed, no chunk is sent and the error channel receives ErrResolverShuttingDown.
+func (r *Resolver) ResolveGraphQLResponseAsync(ctx *Context, response *GraphQLResponse, data []byte) (<-chan []byte, <-chan error) {
+	chunks := make(chan []byte)
+	errs := make(chan error, 1)
+
+	if err := r.startBackground(); err != nil {
+		close(chunks)
+		errs <- err
+		close(errs)
+		return chunks, errs
+	}
+	go func() {
+		defer r.background.Done()
+		defer close(errs)
+		writer := &chunkWriter{chunks: chunks, done: ctx.Context.Done()}
+		var flush func()
+		if r.responseFlushThreshold > 0 {
+			flush = writer.Flush
+		}
+		err := r.resolveGraphQLResponse(ctx, response, data, writer, flush)
+		if err == nil {
+			writer.Flush()
+		}
+		close(chunks)
+		errs <- err
+	}()
+
+	return chunks, errs
+}
+
+// chunkWriter is a FlushWriter sending the data written since the last flush to chunks
+type chunkWriter struct {
+	buf    bytes.Buffer
+	chunks chan<- []byte
+	done   <-chan struct{}
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	return c.buf.Write(p)
+}
+
+func (c *chunkWriter) Flush() {
+	if c.buf.Len() == 0 {
+		return
+	}
+	chunk := make([]byte, c.buf.Len())
+	copy(chunk, c.buf.Bytes())
+	c.buf.Reset()
+	select {
+	case c.chunks <- chunk:
+	case <-c.done:
+	}
+}
+
+func (r *Resolver) resolveGraphQLResponse(ctx *Context, response *GraphQLResponse, data []byte, writer io.Writer, flush func()) (err error) {
+
+	buf := r.getBufPair()
+	defer r.freeBufPair(buf)
+
+	responseBuf := r.getBufPair()
+	defer r.freeBufPair(responseBuf)
+
+	extractResponse(data, responseBuf, ProcessResponseConfig{ExtractGraphqlResponse: true})
+
+	if data != nil {
+		ctx.lastFetchID = initialValueID
+	}
+
+	if r.dataLoaderEnabled {
+		ctx.dataLoader = r.dataloaderFactory.newDataLoader(responseBuf.Data.Bytes())
+		defer func() {
 			r.dataloaderFactory.freeDataLoader(ctx.dataLoader)
 			ctx.dataLoader = nil
 		}()
 	}
 
+	root := response.Data
+	var deniedErr error
+	if ctx.rootFieldMiddleware != nil {
+		root, deniedErr = r.authorizeRootFields(ctx, root, buf)
+	}
+
+	if r.maxFetchesPerOperation > 0 {
+		ctx.fetchCount = new(int64)
+	}
+	if r.staleFetches != nil {
+		ctx.staleFetches = new(int32)
+	}
+	if r.responseStats {
+		ctx.stats = &responseStats{}
+	}
+	if r.apolloTracing {
+		ctx.tracing = newApolloTracing()
+	}
+
+	// the data can only be written while it's resolved if the response isn't rewritten as a whole afterwards
+	var stream *responseStream
+	if flush != nil && r.fetchErrorMode != ErrorModeFailFast && ctx.responseTransform == nil && r.responseEnvelope == nil && !r.prettyPrint && streamable(root) {
+		stream = &responseStream{writer: writer, flush: flush, threshold: r.responseFlushThreshold, buf: buf}
+		ctx.responseStream = stream
+		defer func() {
+			ctx.responseStream = nil
+		}()
+	}
+
 	ignoreData := false
-	err = r.resolveNode(ctx, response.Data, responseBuf.Data.Bytes(), buf)
+	// a denied non-nullable root field nulls the data, so nothing is resolved
+	err = deniedErr
+	if err == nil {
+		err = r.resolveNode(ctx, root, responseBuf.Data.Bytes(), buf)
+	}
 	if err != nil {
-		if !errors.Is(err, errNonNullableFieldValueIsNull) {
+		var fetchErr *fetchFailedError
+		switch {
+		case errors.As(err, &fetchErr):
+			buf.Errors.Reset()
+			buf.Errors.WriteBytes(fetchErr.errors)
+		case !errors.Is(err, errNonNullableFieldValueIsNull):
 			return
 		}
 		ignoreData = true
@@ -505,10 +1456,91 @@ func (r *Resolver) ResolveGraphQLResponse(ctx *Context, response *GraphQLRespons
 		r.MergeBufPairErrors(responseBuf, buf)
 	}
 
-	return writeGraphqlResponse(buf, writer, ignoreData)
+	var extensions []byte
+	if response.Extensions != nil {
+		extensionsBuf := r.getBufPair()
+		defer r.freeBufPair(extensionsBuf)
+		// the fields of the extensions aren't part of the response stats
+		stats := ctx.stats
+		ctx.stats = nil
+		err = r.resolveNode(ctx, response.Extensions, responseBuf.Data.Bytes(), extensionsBuf)
+		ctx.stats = stats
+		if err != nil && !errors.Is(err, errNonNullableFieldValueIsNull) {
+			return
+		}
+		r.MergeBufPairErrors(extensionsBuf, buf)
+		if err == nil {
+			extensions = extensionsBuf.Data.Bytes()
+		}
+	}
+	if ctx.staleFetches != nil && atomic.LoadInt32(ctx.staleFetches) > 0 {
+		extensions = setExtension(extensions, "stale", literal.TRUE)
+	}
+	if r.errorRedactor != nil && buf.HasErrors() {
+		redacted := redactErrors(r.errorRedactor, buf.Errors.Bytes())
+		buf.Errors.Reset()
+		buf.Errors.WriteBytes(redacted)
+	}
+	if ctx.stats != nil {
+		size := buf.Errors.Len()
+		if !ignoreData {
+			size += buf.Data.Len()
+		}
+		if stream != nil {
+			size += stream.written
+		}
+		extensions = setExtension(extensions, "stats", ctx.stats.render(size))
+	}
+	if ctx.tracing != nil {
+		extensions = setExtension(extensions, "tracing", ctx.tracing.render())
+	}
+
+	if r.metrics != nil {
+		counter := &countingWriter{writer: writer}
+		if stream != nil {
+			counter.n = stream.written
+		}
+		defer func() {
+			r.metrics.ObserveResponseBytes(counter.n)
+		}()
+		writer = counter
+	}
+
+	if ctx.responseTransform != nil || r.responseEnvelope != nil {
+		return r.writeBufferedGraphqlResponse(ctx.responseTransform, buf, extensions, writer, ignoreData)
+	}
+	if r.prettyPrint {
+		return writePrettyGraphqlResponse(buf, extensions, writer, ignoreData)
+	}
+
+	if stream != nil && stream.started {
+		return writeStreamedGraphqlResponseEnd(buf, extensions, writer)
+	}
+	return writeGraphqlResponse(buf, extensions, writer, ignoreData)
+}
+
+// setExtension sets key to value in the extensions of a response, extensions is empty if the response has no extensions yet
+func setExtension(extensions []byte, key string, value []byte) []byte {
+	if len(extensions) == 0 {
+		extensions = make([]byte, 0, len(key)+len(value)+5)
+		extensions = append(extensions, `{"`...)
+		extensions = append(extensions, key...)
+		extensions = append(extensions, `":`...)
+		extensions = append(extensions, value...)
+		return append(extensions, '}')
+	}
+	extended, err := jsonparser.Set(extensions, value, key)
+	if err != nil {
+		return extensions
+	}
+	return extended
 }
 
 func (r *Resolver) ResolveGraphQLSubscription(ctx *Context, subscription *GraphQLSubscription, writer FlushWriter) (err error) {
+	if err = r.startBackground(); err != nil {
+		return err
+	}
+	defer r.background.Done()
 
 	buf := r.getBufPair()
 	err = subscription.Trigger.InputTemplate.Render(ctx, nil, buf.Data)
@@ -524,8 +1556,11 @@ func (r *Resolver) ResolveGraphQLSubscription(ctx *Context, subscription *GraphQ
 	defer cancel()
 	resolverDone := r.ctx.Done()
 
+	failure := newSubscriptionError()
+	sourceCtx := context.WithValue(c, subscriptionErrorKey{}, failure)
+
 	next := make(chan []byte)
-	err = subscription.Trigger.Source.Start(c, subscriptionInput, next)
+	err = subscription.Trigger.Source.Start(sourceCtx, subscriptionInput, next)
 	if err != nil {
 		if errors.Is(err, ErrUnableToResolve) {
 			_, err = writer.Write([]byte(`{"errors":[{"message":"unable to resolve"}]}`))
@@ -538,31 +1573,58 @@ func (r *Resolver) ResolveGraphQLSubscription(ctx *Context, subscription *GraphQ
 		return err
 	}
 
+	heartbeat := newSubscriptionHeartbeat(ctx.heartbeatInterval)
+	defer heartbeat.stop()
+
 	for {
 		select {
 		case <-resolverDone:
 			return nil
-		default:
-			data, ok := <-next
+		case <-failure.failed:
+			// the source failed mid-stream, the updates sent before have been written already
+			return r.writeSubscriptionError(failure.err, writer)
+		case <-heartbeat.c:
+			if err = r.writeHeartbeat(ctx, writer); err != nil {
+				return err
+			}
+			heartbeat.reset()
+		case data, ok := <-next:
 			if !ok {
-				return nil
+				select {
+				case <-failure.failed:
+					return r.writeSubscriptionError(failure.err, writer)
+				default:
+					return nil
+				}
 			}
-			err = r.ResolveGraphQLResponse(ctx, subscription.Response, data, writer)
+			err = r.resolveGraphQLResponse(ctx, subscription.Response, data, writer, nil)
 			if err != nil {
 				return err
 			}
 			writer.Flush()
+			heartbeat.reset()
 		}
 	}
 }
 
+func (r *Resolver) writeHeartbeat(ctx *Context, writer FlushWriter) error {
+	if heartbeatWriter, ok := writer.(HeartbeatWriter); ok {
+		return heartbeatWriter.WriteHeartbeat()
+	}
+	if _, err := writer.Write(ctx.heartbeatFrame); err != nil {
+		return err
+	}
+	writer.Flush()
+	return nil
+}
+
 func (r *Resolver) ResolveGraphQLStreamingResponse(ctx *Context, response *GraphQLStreamingResponse, data []byte, writer FlushWriter) (err error) {
 
 	if err := r.validateContext(ctx); err != nil {
 		return err
 	}
 
-	err = r.ResolveGraphQLResponse(ctx, response.InitialResponse, data, writer)
+	err = r.resolveGraphQLResponse(ctx, response.InitialResponse, data, writer, nil)
 	if err != nil {
 		return err
 	}
@@ -706,7 +1768,7 @@ func (r *Resolver) resolveEmptyObject(b *fastbuffer.FastBuffer) {
 
 func (r *Resolver) resolveArray(ctx *Context, array *Array, data []byte, arrayBuf *BufPair) (err error) {
 	if len(array.Path) != 0 {
-		data, _, _, _ = jsonparser.Get(data, array.Path...)
+		data, _, _, _ = r.json.Get(data, array.Path...)
 	}
 
 	if array.UnescapeResponseJson {
@@ -723,15 +1785,24 @@ func (r *Resolver) resolveArray(ctx *Context, array *Array, data []byte, arrayBu
 		*arrayItems = (*arrayItems)[:0]
 		r.byteSlicesPool.Put(arrayItems)
 	}()
+	if r.adaptiveArrayCapacity {
+		if hint := int(atomic.LoadInt32(&array.sizeHint)); hint > cap(*arrayItems) {
+			*arrayItems = make([][]byte, 0, hint)
+		}
+	}
 
-	_, err = jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+	_, err = r.json.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
 		if err == nil && dataType == jsonparser.String {
 			value = data[offset-2 : offset+len(value)] // add quotes to string values
 		}
 
 		*arrayItems = append(*arrayItems, value)
 	})
+	if r.adaptiveArrayCapacity {
+		atomic.StoreInt32(&array.sizeHint, int32(len(*arrayItems)))
+	}
 
+	// the list is null or missing, null items of a non-empty list are handled by the nullability of Item
 	if len(*arrayItems) == 0 {
 		if !array.Nullable {
 			r.resolveEmptyArray(arrayBuf.Data)
@@ -750,11 +1821,88 @@ func (r *Resolver) resolveArray(ctx *Context, array *Array, data []byte, arrayBu
 	return r.resolveArraySynchronous(ctx, array, arrayItems, arrayBuf)
 }
 
+func (r *Resolver) resolveScalarArray(array *ScalarArray, data []byte, arrayBuf *BufPair) error {
+	value, dataType, _, err := r.json.Get(data, array.Path...)
+	if err != nil || dataType != jsonparser.Array {
+		if !array.Nullable {
+			return nonNullableFieldError(err, dataType)
+		}
+		r.resolveNull(arrayBuf.Data)
+		return nil
+	}
+
+	itemType := scalarItemType(array.ItemKind)
+	valid, rewrite := true, false
+	_, _ = r.json.ArrayEach(value, func(_ []byte, dataType jsonparser.ValueType, _ int, _ error) {
+		switch {
+		case dataType == itemType:
+		case array.ItemNullable:
+			// null items can be copied, items of another type have to be replaced with null
+			rewrite = rewrite || dataType != jsonparser.Null
+		default:
+			valid = false
+		}
+	})
+	if !valid {
+		if !array.Nullable {
+			return errNonNullableFieldValueIsNull
+		}
+		r.resolveNull(arrayBuf.Data)
+		return nil
+	}
+	if !rewrite {
+		arrayBuf.Data.WriteBytes(value)
+		return nil
+	}
+
+	arrayBuf.Data.WriteBytes(lBrack)
+	first := true
+	_, _ = r.json.ArrayEach(value, func(item []byte, dataType jsonparser.ValueType, offset int, _ error) {
+		if !first {
+			arrayBuf.Data.WriteBytes(comma)
+		}
+		first = false
+		switch dataType {
+		case itemType:
+			if dataType == jsonparser.String {
+				item = value[offset-2 : offset+len(item)] // add quotes to string values
+			}
+			arrayBuf.Data.WriteBytes(item)
+		default:
+			r.resolveNull(arrayBuf.Data)
+		}
+	})
+	arrayBuf.Data.WriteBytes(rBrack)
+	return nil
+}
+
+// scalarItemType returns the JSON type of the items of a ScalarArray
+func scalarItemType(kind NodeKind) jsonparser.ValueType {
+	switch kind {
+	case NodeKindString:
+		return jsonparser.String
+	case NodeKindBoolean:
+		return jsonparser.Boolean
+	case NodeKindInteger, NodeKindFloat:
+		return jsonparser.Number
+	default:
+		return jsonparser.Unknown
+	}
+}
+
 func (r *Resolver) resolveArraySynchronous(ctx *Context, array *Array, arrayItems *[][]byte, arrayBuf *BufPair) (err error) {
 
 	itemBuf := r.getBufPair()
 	defer r.freeBufPair(itemBuf)
 
+	// items are resolved one after another, so single flight can't deduplicate fetches of items with identical inputs
+	parentFetchCache := ctx.arrayFetchCache
+	ctx.arrayFetchCache = r.getArrayFetchCache()
+	defer func() {
+		r.freeArrayFetchCache(ctx.arrayFetchCache)
+		ctx.arrayFetchCache = parentFetchCache
+	}()
+
 	arrayBuf.Data.WriteBytes(lBrack)
 	var (
 		hasPreviousItem bool
@@ -772,7 +1920,9 @@ func (r *Resolver) resolveArraySynchronous(ctx *Context, array *Array, arrayItem
 		}
 
 		ctx.addIntegerPathElement(i)
+		ctx.arrayIndices = append(ctx.arrayIndices, i)
 		err = r.resolveNode(ctx, array.Item, (*arrayItems)[i], itemBuf)
+		ctx.arrayIndices = ctx.arrayIndices[:len(ctx.arrayIndices)-1]
 		ctx.removeLastPathElement()
 		if err != nil {
 			if errors.Is(err, errNonNullableFieldValueIsNull) && array.Nullable {
@@ -786,6 +1936,9 @@ func (r *Resolver) resolveArraySynchronous(ctx *Context, array *Array, arrayItem
 			}
 			return
 		}
+		if array.SkipNullItems {
+			r.skipNullItem(itemBuf)
+		}
 		dataWritten += itemBuf.Data.Len()
 		r.MergeBufPairs(itemBuf, arrayBuf, hasPreviousItem)
 		if !hasPreviousItem && dataWritten != 0 {
@@ -818,15 +1971,25 @@ func (r *Resolver) resolveArrayAsynchronous(ctx *Context, array *Array, arrayIte
 		itemData := (*arrayItems)[i]
 		cloned := ctx.Clone()
 		go func(ctx Context, i int) {
+			defer wg.Done()
+			defer ctx.Free()
+			// a panic, e.g. in a DataSource, fails the array instead of crashing the process
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					select {
+					case errCh <- fmt.Errorf("%w: item %d: %v", ErrArrayItemPanic, i, recovered):
+					default:
+					}
+				}
+			}()
 			ctx.addPathElement([]byte(strconv.Itoa(i)))
+			ctx.arrayIndices = append(ctx.arrayIndices, i)
 			if e := r.resolveNode(&ctx, array.Item, itemData, itemBuf); e != nil && !errors.Is(e, errTypeNameSkipped) {
 				select {
 				case errCh <- e:
 				default:
 				}
 			}
-			ctx.Free()
-			wg.Done()
 		}(cloned, i)
 	}
 
@@ -851,6 +2014,9 @@ func (r *Resolver) resolveArrayAsynchronous(ctx *Context, array *Array, arrayIte
 		dataWritten     int
 	)
 	for i := range *bufSlice {
+		if array.SkipNullItems {
+			r.skipNullItem((*bufSlice)[i])
+		}
 		dataWritten += (*bufSlice)[i].Data.Len()
 		r.MergeBufPairs((*bufSlice)[i], arrayBuf, hasPreviousItem)
 		if !hasPreviousItem && dataWritten != 0 {
@@ -862,6 +2028,13 @@ func (r *Resolver) resolveArrayAsynchronous(ctx *Context, array *Array, arrayIte
 	return
 }
 
+// skipNullItem drops the data of an item that was resolved to null, errors of the item are kept
+func (r *Resolver) skipNullItem(itemBuf *BufPair) {
+	if bytes.Equal(itemBuf.Data.Bytes(), null) {
+		itemBuf.Data.Reset()
+	}
+}
+
 func (r *Resolver) exportField(ctx *Context, export *FieldExport, value []byte) {
 	if export == nil {
 		return
@@ -872,13 +2045,75 @@ func (r *Resolver) exportField(ctx *Context, export *FieldExport, value []byte)
 	ctx.Variables, _ = jsonparser.Set(ctx.Variables, value, export.Path...)
 }
 
+// getWithFallback returns the value at path if it is of the expected valueType.
+// Otherwise, fallbackPaths are tried in order and the first value of the expected type is returned.
+// If none of the fallbacks matches, the result of the lookup at path is returned.
+func (r *Resolver) getWithFallback(data []byte, path []string, fallbackPaths [][]string, valueType jsonparser.ValueType) ([]byte, jsonparser.ValueType, error) {
+	value, dataType, _, err := r.json.Get(data, path...)
+	if err == nil && dataType == valueType {
+		return value, dataType, err
+	}
+	for i := range fallbackPaths {
+		fallbackValue, fallbackDataType, _, fallbackErr := r.json.Get(data, fallbackPaths[i]...)
+		if fallbackErr == nil && fallbackDataType == valueType {
+			return fallbackValue, fallbackDataType, nil
+		}
+	}
+	if err != nil {
+		if crossErr := r.checkPathCrossesArray(data, path); crossErr != nil {
+			return nil, jsonparser.NotExist, crossErr
+		}
+	}
+	return value, dataType, err
+}
+
+// checkPathCrossesArray returns ErrPathCrossesArray if an element of path other than the last one names an array,
+// e.g. ["edges","node","name"] with edges being a list. A path can't descend into the items of a list,
+// which must be modeled with an Array instead, see Array. It's only called if a value wasn't found, so it's not on the hot path.
+func (r *Resolver) checkPathCrossesArray(data []byte, path []string) error {
+	for i := 1; i < len(path); i++ {
+		if strings.HasPrefix(path[i], "[") {
+			// an array index, e.g. ["edges","[0]","node"], selects a single item
+			continue
+		}
+		_, dataType, _, err := r.json.Get(data, path[:i]...)
+		if err != nil {
+			return nil
+		}
+		if dataType == jsonparser.Array {
+			return fmt.Errorf("%w: %q of path %q is an array, use an Array node for it", ErrPathCrossesArray, path[i-1], strings.Join(path, "."))
+		}
+	}
+	return nil
+}
+
+// resolvePathCrossingArray adds err, an ErrPathCrossesArray, as error of the scalar and resolves it to null.
+// errFieldPathCrossesArray is returned if the scalar is non-nullable, so it nulls its parent like any other missing value.
+func (r *Resolver) resolvePathCrossingArray(ctx *Context, err error, nullable bool, scalarBuf *BufPair) error {
+	r.addError(ctx, scalarBuf, escapeErrorMessage(err.Error()))
+	if !nullable {
+		return errFieldPathCrossesArray
+	}
+	r.resolveNull(scalarBuf.Data)
+	return nil
+}
+
 func (r *Resolver) resolveInteger(ctx *Context, integer *Integer, data []byte, integerBuf *BufPair) error {
-	value, dataType, _, err := jsonparser.Get(data, integer.Path...)
-	if err != nil || dataType != jsonparser.Number {
+	value, dataType, err := r.getWithFallback(data, integer.Path, integer.FallbackPaths, jsonparser.Number)
+	if errors.Is(err, ErrPathCrossesArray) {
+		return r.resolvePathCrossingArray(ctx, err, integer.Nullable, integerBuf)
+	}
+	if err == nil && dataType == jsonparser.String && integer.CoerceFromString {
+		value, dataType = coerceIntegerString(value)
+	}
+	if err != nil || dataType != jsonparser.Number || (r.validateIntRange && !isInt32(value)) {
+		if ctx.nullDebug != nil {
+			ctx.explainNull(data, integer.Path, dataType, err)
+		}
 		if !integer.Nullable {
-			return errNonNullableFieldValueIsNull
+			return nonNullableFieldError(err, dataType)
 		}
-		r.resolveNull(integerBuf.Data)
+		r.resolveNullValue(integerBuf.Data, integer.NullValue)
 		return nil
 	}
 	integerBuf.Data.WriteBytes(value)
@@ -886,13 +2121,58 @@ func (r *Resolver) resolveInteger(ctx *Context, integer *Integer, data []byte, i
 	return nil
 }
 
+func isInt32(value []byte) bool {
+	i, err := jsonparser.ParseInt(value)
+	return err == nil && i >= math.MinInt32 && i <= math.MaxInt32
+}
+
+// coerceIntegerString returns the integer of a string value like "42" as number, the type stays jsonparser.String if it's no integer
+func coerceIntegerString(value []byte) ([]byte, jsonparser.ValueType) {
+	i, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return value, jsonparser.String
+	}
+	return strconv.AppendInt(nil, i, 10), jsonparser.Number
+}
+
+// coerceBoolean returns the boolean of the encodings 0/1 and "false"/"true" as JSON boolean,
+// other values are returned unchanged
+func coerceBoolean(value []byte, valueType jsonparser.ValueType) ([]byte, jsonparser.ValueType) {
+	switch {
+	case valueType == jsonparser.Number && string(value) == "1",
+		valueType == jsonparser.String && string(value) == "true":
+		return literal.TRUE, jsonparser.Boolean
+	case valueType == jsonparser.Number && string(value) == "0",
+		valueType == jsonparser.String && string(value) == "false":
+		return literal.FALSE, jsonparser.Boolean
+	}
+	return value, valueType
+}
+
+// coerceFloatString returns the number of a string value like "4.2" as number, the type stays jsonparser.String if it's no JSON number
+func coerceFloatString(value []byte) ([]byte, jsonparser.ValueType) {
+	if len(value) == 0 || (value[0] != '-' && (value[0] < '0' || value[0] > '9')) || !json.Valid(value) {
+		return value, jsonparser.String
+	}
+	return value, jsonparser.Number
+}
+
 func (r *Resolver) resolveFloat(ctx *Context, floatValue *Float, data []byte, floatBuf *BufPair) error {
-	value, dataType, _, err := jsonparser.Get(data, floatValue.Path...)
+	value, dataType, err := r.getWithFallback(data, floatValue.Path, floatValue.FallbackPaths, jsonparser.Number)
+	if errors.Is(err, ErrPathCrossesArray) {
+		return r.resolvePathCrossingArray(ctx, err, floatValue.Nullable, floatBuf)
+	}
+	if err == nil && dataType == jsonparser.String && floatValue.CoerceFromString {
+		value, dataType = coerceFloatString(value)
+	}
 	if err != nil || dataType != jsonparser.Number {
+		if ctx.nullDebug != nil {
+			ctx.explainNull(data, floatValue.Path, dataType, err)
+		}
 		if !floatValue.Nullable {
-			return errNonNullableFieldValueIsNull
+			return nonNullableFieldError(err, dataType)
 		}
-		r.resolveNull(floatBuf.Data)
+		r.resolveNullValue(floatBuf.Data, floatValue.NullValue)
 		return nil
 	}
 	floatBuf.Data.WriteBytes(value)
@@ -901,12 +2181,21 @@ func (r *Resolver) resolveFloat(ctx *Context, floatValue *Float, data []byte, fl
 }
 
 func (r *Resolver) resolveBoolean(ctx *Context, boolean *Boolean, data []byte, booleanBuf *BufPair) error {
-	value, valueType, _, err := jsonparser.Get(data, boolean.Path...)
+	value, valueType, err := r.getWithFallback(data, boolean.Path, boolean.FallbackPaths, jsonparser.Boolean)
+	if errors.Is(err, ErrPathCrossesArray) {
+		return r.resolvePathCrossingArray(ctx, err, boolean.Nullable, booleanBuf)
+	}
+	if err == nil && boolean.CoerceFromNumberOrString {
+		value, valueType = coerceBoolean(value, valueType)
+	}
 	if err != nil || valueType != jsonparser.Boolean {
+		if ctx.nullDebug != nil {
+			ctx.explainNull(data, boolean.Path, valueType, err)
+		}
 		if !boolean.Nullable {
-			return errNonNullableFieldValueIsNull
+			return nonNullableFieldError(err, valueType)
 		}
-		r.resolveNull(booleanBuf.Data)
+		r.resolveNullValue(booleanBuf.Data, boolean.NullValue)
 		return nil
 	}
 	booleanBuf.Data.WriteBytes(value)
@@ -914,6 +2203,14 @@ func (r *Resolver) resolveBoolean(ctx *Context, boolean *Boolean, data []byte, b
 	return nil
 }
 
+// nonNullableFieldError distinguishes an explicit null returned by the origin from a missing value or a type mismatch
+func nonNullableFieldError(err error, valueType jsonparser.ValueType) error {
+	if err == nil && valueType == jsonparser.Null {
+		return errNonNullableFieldValueIsExplicitNull
+	}
+	return errNonNullableFieldValueIsNull
+}
+
 func (r *Resolver) resolveString(ctx *Context, str *String, data []byte, stringBuf *BufPair) error {
 	var (
 		value     []byte
@@ -921,7 +2218,10 @@ func (r *Resolver) resolveString(ctx *Context, str *String, data []byte, stringB
 		err       error
 	)
 
-	value, valueType, _, err = jsonparser.Get(data, str.Path...)
+	value, valueType, err = r.getWithFallback(data, str.Path, str.FallbackPaths, jsonparser.String)
+	if errors.Is(err, ErrPathCrossesArray) {
+		return r.resolvePathCrossingArray(ctx, err, str.Nullable, stringBuf)
+	}
 	if err != nil || valueType != jsonparser.String {
 		if err == nil && str.UnescapeResponseJson {
 			switch valueType {
@@ -930,10 +2230,13 @@ func (r *Resolver) resolveString(ctx *Context, str *String, data []byte, stringB
 				return nil
 			}
 		}
+		if ctx.nullDebug != nil {
+			ctx.explainNull(data, str.Path, valueType, err)
+		}
 		if !str.Nullable {
-			return errNonNullableFieldValueIsNull
+			return nonNullableFieldError(err, valueType)
 		}
-		r.resolveNull(stringBuf.Data)
+		r.resolveNullValue(stringBuf.Data, str.NullValue)
 		return nil
 	}
 
@@ -948,7 +2251,9 @@ func (r *Resolver) resolveString(ctx *Context, str *String, data []byte, stringB
 		return nil
 	}
 
-	value = r.renameTypeName(ctx, str, value)
+	if str.IsTypeName {
+		value = r.renameTypeName(ctx, value)
+	}
 
 	stringBuf.Data.WriteBytes(quote)
 	stringBuf.Data.WriteBytes(value)
@@ -957,10 +2262,120 @@ func (r *Resolver) resolveString(ctx *Context, str *String, data []byte, stringB
 	return nil
 }
 
-func (r *Resolver) renameTypeName(ctx *Context, str *String, typeName []byte) []byte {
-	if !str.IsTypeName {
-		return typeName
+func (r *Resolver) resolveJSONString(jsonString *JSONString, data []byte, jsonStringBuf *BufPair) error {
+	value, valueType, offset, err := r.json.Get(data, jsonString.Path...)
+	if err != nil || valueType == jsonparser.Null {
+		if !jsonString.Nullable {
+			return errNonNullableFieldValueIsNull
+		}
+		r.resolveNull(jsonStringBuf.Data)
+		return nil
+	}
+	if valueType == jsonparser.String {
+		// add quotes to string values
+		value = data[offset-len(value)-2 : offset]
+	}
+
+	jsonStringBuf.Data.WriteBytes(quote)
+	writeEscapedJSONString(jsonStringBuf.Data, value)
+	jsonStringBuf.Data.WriteBytes(quote)
+	return nil
+}
+
+// writeEscapedJSONString writes value so that it can be embedded into a JSON string
+func writeEscapedJSONString(buf *fastbuffer.FastBuffer, value []byte) {
+	const hex = "0123456789abcdef"
+	start := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			continue
+		}
+		buf.WriteBytes(value[start:i])
+		switch c {
+		case '"', '\\':
+			buf.WriteBytes([]byte{'\\', c})
+		case '\n':
+			buf.WriteBytes([]byte{'\\', 'n'})
+		case '\r':
+			buf.WriteBytes([]byte{'\\', 'r'})
+		case '\t':
+			buf.WriteBytes([]byte{'\\', 't'})
+		default:
+			buf.WriteBytes([]byte{'\\', 'u', '0', '0', hex[c>>4], hex[c&0xF]})
+		}
+		start = i + 1
+	}
+	buf.WriteBytes(value[start:])
+}
+
+func (r *Resolver) resolveTypeName(ctx *Context, typeName *TypeName, data []byte, typeNameBuf *BufPair) error {
+	value := typeName.Value
+	if value == nil {
+		value = typeNameFromData(r.json, data, typeName.Path)
+	}
+	if len(value) == 0 {
+		if !typeName.Nullable {
+			return errNonNullableFieldValueIsNull
+		}
+		r.resolveNull(typeNameBuf.Data)
+		return nil
+	}
+
+	value = r.renameTypeName(ctx, value)
+
+	typeNameBuf.Data.WriteBytes(quote)
+	typeNameBuf.Data.WriteBytes(value)
+	typeNameBuf.Data.WriteBytes(quote)
+	return nil
+}
+
+// typeNameFromData reads the concrete type name at path, it defaults to the __typename field of data.
+// It is used for both TypeName nodes and the Field.OnTypeName condition so that both always agree on the type of an object.
+func typeNameFromData(json JSONAccessor, data []byte, path []string) []byte {
+	if len(path) == 0 {
+		path = typeNamePath
+	}
+	typeName, dataType, _, err := json.Get(data, path...)
+	if err != nil || dataType != jsonparser.String {
+		return nil
+	}
+	return typeName
+}
+
+// objectTypeName returns the type name of the object data which is evaluated by the Field.OnTypeName conditions of the object
+func objectTypeName(json JSONAccessor, object *Object, data []byte) []byte {
+	if object.TypeDiscriminator != nil {
+		return object.TypeDiscriminator.typeName(json, data)
+	}
+	return typeNameFromData(json, data, nil)
+}
+
+// typeNameCache holds the type name of the data of the last evaluated Field.OnTypeName condition of an object,
+// so fields with type conditions sharing the same data, e.g. the data of the object or of the same buffer, read it once
+type typeNameCache struct {
+	data     []byte
+	typeName []byte
+	resolved bool
+}
+
+func (c *typeNameCache) objectTypeName(json JSONAccessor, object *Object, data []byte) []byte {
+	if c.resolved && sameBytes(c.data, data) {
+		return c.typeName
 	}
+	c.data, c.typeName, c.resolved = data, objectTypeName(json, object, data), true
+	return c.typeName
+}
+
+// sameBytes returns true if a and b are the same slice of the same backing array
+func sameBytes(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return len(a) == 0 || &a[0] == &b[0]
+}
+
+func (r *Resolver) renameTypeName(ctx *Context, typeName []byte) []byte {
 	for i := range ctx.RenameTypeNames {
 		if bytes.Equal(ctx.RenameTypeNames[i].From, typeName) {
 			return ctx.RenameTypeNames[i].To
@@ -981,27 +2396,176 @@ func (r *Resolver) resolveNull(b *fastbuffer.FastBuffer) {
 	b.WriteBytes(null)
 }
 
-func (r *Resolver) addResolveError(ctx *Context, objectBuf *BufPair) {
-	locations, path := pool.BytesBuffer.Get(), pool.BytesBuffer.Get()
-	defer pool.BytesBuffer.Put(locations)
-	defer pool.BytesBuffer.Put(path)
+// resolveNullValue writes the custom null value of a node, or null if none is set
+func (r *Resolver) resolveNullValue(b *fastbuffer.FastBuffer, nullValue []byte) {
+	if nullValue == nil {
+		r.resolveNull(b)
+		return
+	}
+	b.WriteBytes(nullValue)
+}
 
-	var pathBytes []byte
+// authorizeRootFields runs the RootFieldMiddleware for each field of the root object.
+// If at least one field is denied, a shallow copy of the root object is returned in which denied fields resolve to null
+// and fetches that exclusively serve denied fields are removed. The plan itself is never modified as it might be cached.
+// errFieldForbidden is returned if a non-nullable field is denied, as the null propagates to the data like in resolveObject.
+func (r *Resolver) authorizeRootFields(ctx *Context, root Node, buf *BufPair) (Node, error) {
+	object, ok := root.(*Object)
+	if !ok {
+		return root, nil
+	}
 
-	locations.Write(lBrack)
-	locations.Write(lBrace)
-	locations.Write(quote)
-	locations.Write(literalLine)
-	locations.Write(quote)
-	locations.Write(colon)
-	locations.Write([]byte(strconv.Itoa(int(ctx.position.Line))))
-	locations.Write(comma)
-	locations.Write(quote)
-	locations.Write(literalColumn)
-	locations.Write(quote)
-	locations.Write(colon)
-	locations.Write([]byte(strconv.Itoa(int(ctx.position.Column))))
-	locations.Write(rBrace)
+	var denied []bool
+	for i := range object.Fields {
+		err := ctx.rootFieldMiddleware.OnRootField(ctx, object.Fields[i].Name)
+		if err == nil {
+			continue
+		}
+		if denied == nil {
+			denied = make([]bool, len(object.Fields))
+		}
+		denied[i] = true
+		ctx.setPosition(object.Fields[i].Position)
+		ctx.addPathElement(object.Fields[i].Name)
+		r.addError(ctx, buf, escapeErrorMessage(err.Error()))
+		ctx.removeLastPathElement()
+	}
+	ctx.setPosition(Position{})
+
+	if denied == nil {
+		return root, nil
+	}
+	for i := range object.Fields {
+		if denied[i] && !nodeNullable(object.Fields[i].Value) {
+			return root, errFieldForbidden
+		}
+	}
+
+	authorized := *object
+	authorized.Fields = make([]*Field, len(object.Fields))
+	for i := range object.Fields {
+		if !denied[i] {
+			authorized.Fields[i] = object.Fields[i]
+			continue
+		}
+		field := *object.Fields[i]
+		field.Value = &Null{}
+		field.HasBuffer = false
+		authorized.Fields[i] = &field
+	}
+
+	authorized.Fetch = r.withoutUnusedFetches(object.Fetch, authorized.Fields)
+	return &authorized, nil
+}
+
+// withoutUnusedFetches returns the fetch without all (nested) single fetches whose buffer is not used by any of the fields
+func (r *Resolver) withoutUnusedFetches(fetch Fetch, fields []*Field) Fetch {
+	isUsed := func(bufferID int) bool {
+		for i := range fields {
+			if fields[i].HasBuffer && fields[i].BufferID == bufferID {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch f := fetch.(type) {
+	case *SingleFetch:
+		if !isUsed(f.BufferId) {
+			return nil
+		}
+	case *BatchFetch:
+		if !isUsed(f.Fetch.BufferId) {
+			return nil
+		}
+	case *RaceFetch:
+		if !isUsed(f.BufferId) {
+			return nil
+		}
+	case *ParallelFetch:
+		fetches := make([]Fetch, 0, len(f.Fetches))
+		for i := range f.Fetches {
+			if used := r.withoutUnusedFetches(f.Fetches[i], fields); used != nil {
+				fetches = append(fetches, used)
+			}
+		}
+		if len(fetches) == 0 {
+			return nil
+		}
+		return &ParallelFetch{Fetches: fetches}
+	}
+	return fetch
+}
+
+// escapeErrorMessage turns a plain error message into the content of a JSON string
+func escapeErrorMessage(message string) []byte {
+	escaped, _ := json.Marshal(message)
+	return escaped[1 : len(escaped)-1]
+}
+
+// redactErrors returns a copy of errs, the comma separated JSON objects of one or more errors,
+// with redactor applied to the message of each error
+func redactErrors(redactor ErrorRedactor, errs []byte) []byte {
+	list := make([]byte, 0, len(errs)+2)
+	list = append(list, lBrack...)
+	list = append(list, errs...)
+	list = append(list, rBrack...)
+
+	redacted := make([]byte, 0, len(errs))
+	_, _ = jsonparser.ArrayEach(list, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if len(redacted) != 0 {
+			redacted = append(redacted, comma...)
+		}
+		message, messageType, _, err := jsonparser.Get(value, "message")
+		if err != nil || messageType != jsonparser.String {
+			redacted = append(redacted, value...)
+			return
+		}
+		replacement := redactor(message)
+		quoted := make([]byte, 0, len(replacement)+2)
+		quoted = append(quoted, quote...)
+		quoted = append(quoted, replacement...)
+		quoted = append(quoted, quote...)
+		// Set may modify the passed data, so the error is copied to not corrupt the list being iterated
+		value, err = jsonparser.Set(append([]byte(nil), value...), quoted, "message")
+		if err != nil {
+			// the original error must not be written, so only the redacted message is kept
+			value = append(append(append([]byte(nil), `{"message":`...), quoted...), rBrace...)
+		}
+		redacted = append(redacted, value...)
+	})
+	return redacted
+}
+
+func (r *Resolver) addResolveError(ctx *Context, objectBuf *BufPair) {
+	r.addError(ctx, objectBuf, unableToResolveMsg)
+}
+
+func (r *Resolver) addError(ctx *Context, objectBuf *BufPair, message []byte) {
+	r.addErrorWithExtensions(ctx, objectBuf, message, nil)
+}
+
+func (r *Resolver) addErrorWithExtensions(ctx *Context, objectBuf *BufPair, message, extensions []byte) {
+	locations, path := pool.BytesBuffer.Get(), pool.BytesBuffer.Get()
+	defer pool.BytesBuffer.Put(locations)
+	defer pool.BytesBuffer.Put(path)
+
+	var pathBytes []byte
+
+	locations.Write(lBrack)
+	locations.Write(lBrace)
+	locations.Write(quote)
+	locations.Write(literalLine)
+	locations.Write(quote)
+	locations.Write(colon)
+	locations.Write([]byte(strconv.Itoa(int(ctx.position.Line))))
+	locations.Write(comma)
+	locations.Write(quote)
+	locations.Write(literalColumn)
+	locations.Write(quote)
+	locations.Write(colon)
+	locations.Write([]byte(strconv.Itoa(int(ctx.position.Column))))
+	locations.Write(rBrace)
 	locations.Write(rBrack)
 
 	if len(ctx.pathElements) > 0 {
@@ -1014,14 +2578,36 @@ func (r *Resolver) addResolveError(ctx *Context, objectBuf *BufPair) {
 		pathBytes = path.Bytes()
 	}
 
-	objectBuf.WriteErr(unableToResolveMsg, locations.Bytes(), pathBytes, nil)
+	if ctx.earlyErrors != nil {
+		early := r.getBufPair()
+		defer r.freeBufPair(early)
+		early.WriteErr(message, locations.Bytes(), pathBytes, extensions)
+		ctx.earlyErrors.write(early.Errors.Bytes())
+	}
+
+	objectBuf.WriteErr(message, locations.Bytes(), pathBytes, extensions)
 }
 
 func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, objectBuf *BufPair) (err error) {
-	if len(object.Path) != 0 {
-		data, _, _, _ = jsonparser.Get(data, object.Path...)
+	if r.sortKeys {
+		object = objectWithSortedFields(object)
+	}
+
+	if len(object.Path) != 0 || len(object.KeyPath) != 0 {
+		// keyed objects are looked up by the parent object including their Path, see keyedObjectData
+		parentData := data
+		var (
+			valueType jsonparser.ValueType
+			getErr    error
+		)
+		if len(object.KeyPath) == 0 {
+			data, valueType, _, getErr = r.json.Get(data, object.Path...)
+		}
 
 		if len(data) == 0 || bytes.Equal(data, literal.NULL) {
+			if ctx.nullDebug != nil && len(object.KeyPath) == 0 {
+				ctx.explainNull(parentData, object.Path, valueType, getErr)
+			}
 			if object.Nullable {
 				r.resolveNull(objectBuf.Data)
 				return
@@ -1039,6 +2625,20 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 		data = bytes.ReplaceAll(data, []byte(`\"`), []byte(`"`))
 	}
 
+	if object.Fetch == nil && objectBuf.Data.Len() == 0 && ctx.fieldAuthorizer == nil && ctx.nullDebug == nil && isPlainObject(object) {
+		errorsLen := objectBuf.Errors.Len()
+		if r.resolvePlainObject(ctx, object, data, objectBuf) == nil {
+			// fields are counted once the fast path succeeded, the regular path counts them itself
+			if ctx.stats != nil {
+				ctx.stats.countFields(len(object.Fields))
+			}
+			return nil
+		}
+		// errors are handled by the regular path, so the data and errors written by the fast path are dropped
+		objectBuf.Data.Reset()
+		truncateBuffer(objectBuf.Errors, errorsLen)
+	}
+
 	var set *resultSet
 	if object.Fetch != nil {
 		set = r.getResultSet()
@@ -1047,8 +2647,11 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 		if err != nil {
 			return
 		}
-		for i := range set.buffers {
-			r.MergeBufPairErrors(set.buffers[i], objectBuf)
+		for _, id := range set.bufferIDs {
+			if ctx.earlyErrors != nil {
+				ctx.earlyErrors.write(set.buffers[id].Errors.Bytes())
+			}
+			r.MergeBufPairErrors(set.buffers[id], objectBuf)
 		}
 	}
 
@@ -1058,25 +2661,19 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 	responseElements := ctx.responseElements
 	lastFetchID := ctx.lastFetchID
 
+	var typeNames typeNameCache
 	typeNameSkip := false
 	first := true
 	skipCount := 0
 	for i := range object.Fields {
 
-		if object.Fields[i].SkipDirectiveDefined {
-			skip, err := jsonparser.GetBoolean(ctx.Variables, object.Fields[i].SkipVariableName)
-			if err == nil && skip {
-				skipCount++
-				continue
-			}
+		if r.skippedByDirective(ctx, object.Fields[i]) {
+			skipCount++
+			continue
 		}
 
-		if object.Fields[i].IncludeDirectiveDefined {
-			include, err := jsonparser.GetBoolean(ctx.Variables, object.Fields[i].IncludeVariableName)
-			if err != nil || !include {
-				skipCount++
-				continue
-			}
+		if object.Fields[i].Deduplicate && r.previousFieldWritten(ctx, object, i, data, set, &typeNames) {
+			continue
 		}
 
 		var fieldData []byte
@@ -1091,8 +2688,12 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 			fieldData = data
 		}
 
+		if keyed, ok := object.Fields[i].Value.(*Object); ok && len(keyed.KeyPath) != 0 {
+			fieldData = r.keyedObjectData(keyed, data, fieldData)
+		}
+
 		if object.Fields[i].OnTypeName != nil {
-			typeName, _, _, _ := jsonparser.Get(fieldData, "__typename")
+			typeName := typeNames.objectTypeName(r.json, object, fieldData)
 			if !bytes.Equal(typeName, object.Fields[i].OnTypeName) {
 				typeNameSkip = true
 				// Restore the response elements that may have been reset above.
@@ -1102,6 +2703,10 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 			}
 		}
 
+		if object.Fields[i].NoCache && ctx.cacheControl != nil {
+			ctx.cacheControl.recordNoCache()
+		}
+
 		if first {
 			objectBuf.Data.WriteBytes(lBrace)
 			first = false
@@ -1114,7 +2719,13 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 		objectBuf.Data.WriteBytes(colon)
 		ctx.addPathElement(object.Fields[i].Name)
 		ctx.setPosition(object.Fields[i].Position)
-		err = r.resolveNode(ctx, object.Fields[i].Value, fieldData, fieldBuf)
+		denied := false
+		if ctx.fieldAuthorizer != nil {
+			denied, err = r.authorizeField(ctx, object.Fields[i], fieldBuf)
+		}
+		if !denied {
+			err = r.resolveFieldValue(ctx, object.Fields[i], fieldData, fieldBuf)
+		}
 		ctx.removeLastPathElement()
 		ctx.responseElements = responseElements
 		ctx.lastFetchID = lastFetchID
@@ -1134,14 +2745,32 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 				}
 
 				// if fied is of object type than we should not add resolve error here
-				if _, ok := object.Fields[i].Value.(*Object); !ok {
-					r.addResolveError(ctx, objectBuf)
+				if _, ok := object.Fields[i].Value.(*Object); !ok && !errors.Is(err, errFieldTimeout) && !errors.Is(err, errFieldForbidden) && !errors.Is(err, errFieldPathCrossesArray) {
+					if r.log != nil {
+						r.log.Warn("resolve.Resolver.resolveObject: non-nullable field is null",
+							abstractlogger.String("path", string(ctx.path())),
+							abstractlogger.ByteString("field", object.Fields[i].Name),
+						)
+					}
+					if errors.Is(err, errNonNullableFieldValueIsExplicitNull) {
+						r.addError(ctx, objectBuf, nonNullableFieldIsNullMsg)
+					} else {
+						r.addResolveError(ctx, objectBuf)
+					}
 				}
 			}
 
 			return
 		}
 		r.MergeBufPairs(fieldBuf, objectBuf, false)
+		if ctx.stats != nil {
+			ctx.stats.countField()
+		}
+		if ctx.responseStream != nil && ctx.responseStream.buf == objectBuf {
+			if err = ctx.responseStream.fieldResolved(); err != nil {
+				return
+			}
+		}
 	}
 	allSkipped := len(object.Fields) != 0 && len(object.Fields) == skipCount
 	if allSkipped {
@@ -1161,125 +2790,749 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 		r.resolveNull(objectBuf.Data)
 		return
 	}
-	objectBuf.Data.WriteBytes(rBrace)
-	return
-}
+	objectBuf.Data.WriteBytes(rBrace)
+	return
+}
+
+// objectWithSortedFields returns a shallow copy of object with the fields sorted by name,
+// fields with the same name keep their order so the first one is written, see Field.Deduplicate.
+// object itself is returned without allocating if its fields are sorted already, e.g. by postprocess.ProcessSortFields.
+func objectWithSortedFields(object *Object) *Object {
+	if fieldsSortedByName(object.Fields) {
+		return object
+	}
+	sorted := *object
+	sorted.Fields = make([]*Field, len(object.Fields))
+	copy(sorted.Fields, object.Fields)
+	sort.SliceStable(sorted.Fields, func(i, j int) bool {
+		return bytes.Compare(sorted.Fields[i].Name, sorted.Fields[j].Name) < 0
+	})
+	return &sorted
+}
+
+func fieldsSortedByName(fields []*Field) bool {
+	for i := 1; i < len(fields); i++ {
+		if bytes.Compare(fields[i-1].Name, fields[i].Name) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isPlainObject returns true if all fields of the object are scalars without buffer, type condition, directives, defer or timeout
+func isPlainObject(object *Object) bool {
+	if len(object.Fields) == 0 {
+		return false
+	}
+	for _, field := range object.Fields {
+		if field.HasBuffer || field.OnTypeName != nil || field.SkipDirectiveDefined || field.IncludeDirectiveDefined ||
+			field.Defer != nil || field.Stream != nil || field.Deduplicate || field.NoCache || field.Timeout != 0 {
+			return false
+		}
+		switch field.Value.(type) {
+		case *String, *Integer, *Float, *Boolean:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// fieldTimeoutOf returns the timeout of field, WithFieldTimeout only applies to objects and arrays
+func (r *Resolver) fieldTimeoutOf(field *Field) time.Duration {
+	if field.Timeout != 0 {
+		return field.Timeout
+	}
+	switch field.Value.(type) {
+	case *Object, *Array:
+		return r.fieldTimeout
+	}
+	return 0
+}
+
+// resolveFieldValue resolves the value of field with a context limited to the timeout of the field.
+// If the timeout is exceeded, the partial value and its errors are replaced by null and a timeout error.
+func (r *Resolver) resolveFieldValue(ctx *Context, field *Field, data []byte, fieldBuf *BufPair) error {
+	timeout := r.fieldTimeoutOf(field)
+	if timeout <= 0 {
+		return r.resolveNode(ctx, field.Value, data, fieldBuf)
+	}
+
+	parent := ctx.Context
+	fieldCtx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	ctx.Context = fieldCtx
+	err := r.resolveNode(ctx, field.Value, data, fieldBuf)
+	ctx.Context = parent
+
+	if !errors.Is(fieldCtx.Err(), context.DeadlineExceeded) || parent.Err() != nil {
+		return err
+	}
+	fieldBuf.Reset()
+	r.addError(ctx, fieldBuf, []byte(fmt.Sprintf("field timed out after %s", timeout)))
+	if !nodeNullable(field.Value) {
+		return errFieldTimeout
+	}
+	r.resolveNull(fieldBuf.Data)
+	return nil
+}
+
+// authorizeField runs the FieldAuthorizer for field, a denied field resolves to null with a FORBIDDEN error instead of its value.
+// errFieldForbidden is returned if a denied field is non-nullable.
+func (r *Resolver) authorizeField(ctx *Context, field *Field, fieldBuf *BufPair) (denied bool, err error) {
+	authErr := ctx.fieldAuthorizer.AuthorizeField(ctx, ctx.path(), field)
+	if authErr == nil {
+		return false, nil
+	}
+	r.addErrorWithExtensions(ctx, fieldBuf, escapeErrorMessage(authErr.Error()), forbiddenErrorExtensions)
+	if !nodeNullable(field.Value) {
+		return true, errFieldForbidden
+	}
+	r.resolveNull(fieldBuf.Data)
+	return true, nil
+}
+
+// nodeNullable returns true if node may resolve to null
+func nodeNullable(node Node) bool {
+	switch n := node.(type) {
+	case *Object:
+		return n.Nullable
+	case *Array:
+		return n.Nullable
+	case *ScalarArray:
+		return n.Nullable
+	case *String:
+		return n.Nullable
+	case *TypeName:
+		return n.Nullable
+	case *JSONString:
+		return n.Nullable
+	case *Boolean:
+		return n.Nullable
+	case *Float:
+		return n.Nullable
+	case *Integer:
+		return n.Nullable
+	}
+	return true
+}
+
+// resolvePlainObject is the fast path of resolveObject for plain objects, see isPlainObject.
+// Scalars only append to the buffer, so they are written to objectBuf directly instead of merging field buffers.
+func (r *Resolver) resolvePlainObject(ctx *Context, object *Object, data []byte, objectBuf *BufPair) error {
+	objectBuf.Data.WriteBytes(lBrace)
+	for i, field := range object.Fields {
+		if i != 0 {
+			objectBuf.Data.WriteBytes(comma)
+		}
+		objectBuf.Data.WriteBytes(quote)
+		objectBuf.Data.WriteBytes(field.Name)
+		objectBuf.Data.WriteBytes(quote)
+		objectBuf.Data.WriteBytes(colon)
+		ctx.addPathElement(field.Name)
+		ctx.setPosition(field.Position)
+		err := r.resolveNode(ctx, field.Value, data, objectBuf)
+		ctx.removeLastPathElement()
+		if err != nil {
+			return err
+		}
+	}
+	objectBuf.Data.WriteBytes(rBrace)
+	return nil
+}
+
+// truncateBuffer drops everything written to buf after its first n bytes
+func truncateBuffer(buf *fastbuffer.FastBuffer, n int) {
+	kept := buf.Bytes()[:n]
+	buf.Reset()
+	// kept shares the memory of buf, so writing it back copies it in place
+	buf.WriteBytes(kept)
+}
+
+func (r *Resolver) skippedByDirective(ctx *Context, field *Field) bool {
+	if field.SkipDirectiveDefined {
+		skip, err := jsonparser.GetBoolean(ctx.Variables, field.SkipVariableName)
+		if err == nil && skip {
+			return true
+		}
+	}
+	if field.IncludeDirectiveDefined {
+		include, err := jsonparser.GetBoolean(ctx.Variables, field.IncludeVariableName)
+		if err != nil || !include {
+			return true
+		}
+	}
+	return false
+}
+
+// previousFieldWritten returns true if a field preceding the field at index i with the same response key has been written,
+// so the response key keeps the position of its first selection
+func (r *Resolver) previousFieldWritten(ctx *Context, object *Object, i int, data []byte, set *resultSet, typeNames *typeNameCache) bool {
+	for _, previous := range object.Fields[:i] {
+		if !bytes.Equal(previous.Name, object.Fields[i].Name) || r.skippedByDirective(ctx, previous) {
+			continue
+		}
+		if previous.OnTypeName == nil {
+			return true
+		}
+		fieldData := data
+		if set != nil && previous.HasBuffer {
+			fieldData = nil
+			if buffer, ok := set.buffers[previous.BufferID]; ok {
+				fieldData = buffer.Data.Bytes()
+			}
+		}
+		if bytes.Equal(typeNames.objectTypeName(r.json, object, fieldData), previous.OnTypeName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Resolver) freeResultSet(set *resultSet) {
+	for i := range set.buffers {
+		set.buffers[i].Reset()
+		r.bufPairPool.Put(set.buffers[i])
+		delete(set.buffers, i)
+	}
+	set.bufferIDs = set.bufferIDs[:0]
+	r.resultSetPool.Put(set)
+}
+
+// keyedObjectData returns the entry of the keyed data at object.Path identified by the value at object.KeyPath of parentData
+func (r *Resolver) keyedObjectData(object *Object, parentData, keyedData []byte) []byte {
+	key, keyType, _, err := r.json.Get(parentData, object.KeyPath...)
+	if err != nil || (keyType != jsonparser.String && keyType != jsonparser.Number) {
+		return nil
+	}
+	if len(object.Path) != 0 {
+		keyedData, _, _, _ = r.json.Get(keyedData, object.Path...)
+	}
+	entry, _, _, _ := r.json.Get(keyedData, string(key))
+	return entry
+}
+
+func (r *Resolver) resolveFetch(ctx *Context, fetch Fetch, data []byte, set *resultSet) (err error) {
+
+	switch f := fetch.(type) {
+	case *SingleFetch:
+		preparedInput := r.getBufPair()
+		defer r.freeBufPair(preparedInput)
+		err = r.prepareSingleFetch(ctx, f, data, set, preparedInput.Data)
+		if err != nil {
+			return err
+		}
+		err = r.resolveSingleFetch(ctx, f, preparedInput.Data, set.buffers[f.BufferId])
+	case *BatchFetch:
+		preparedInput := r.getBufPair()
+		defer r.freeBufPair(preparedInput)
+		err = r.prepareSingleFetch(ctx, f.Fetch, data, set, preparedInput.Data)
+		if err != nil {
+			return err
+		}
+		err = r.resolveBatchFetch(ctx, f, preparedInput.Data, set.buffers[f.Fetch.BufferId])
+	case *ParallelFetch:
+		err = r.resolveParallelFetch(ctx, f, data, set)
+	case *RaceFetch:
+		err = r.resolveRaceFetch(ctx, f, data, set)
+	case *TypeConditionalFetch:
+		if selected := f.selectFetch(r.json, data); selected != nil {
+			err = r.resolveFetch(ctx, selected, data, set)
+		}
+	}
+	return
+}
+
+func (r *Resolver) resolveParallelFetch(ctx *Context, fetch *ParallelFetch, data []byte, set *resultSet) (err error) {
+	preparedInputs := r.getBufPairSlice()
+	defer r.freeBufPairSlice(preparedInputs)
+
+	resolvers := make([]func() error, 0, len(fetch.Fetches))
+	bufs := make([]*BufPair, 0, len(fetch.Fetches))
+	errorExtensions := make([][]byte, 0, len(fetch.Fetches))
+
+	wg := r.getWaitGroup()
+	defer r.freeWaitGroup(wg)
+
+	// in ErrorModeFailFast the first failed fetch aborts the response, so its siblings are cancelled
+	fetchCtx := ctx
+	cancel := func() {}
+	if r.fetchErrorMode == ErrorModeFailFast && len(fetch.Fetches) > 1 {
+		cancellable := *ctx
+		cancellable.Context, cancel = context.WithCancel(ctx.Context)
+		defer cancel()
+		fetchCtx = &cancellable
+	}
+
+	for i := range fetch.Fetches {
+		// a TypeConditionalFetch is replaced by the fetch selected for the data, it's skipped if no condition matches
+		selected := fetch.Fetches[i]
+		for {
+			conditional, ok := selected.(*TypeConditionalFetch)
+			if !ok {
+				break
+			}
+			selected = conditional.selectFetch(r.json, data)
+		}
+		switch f := selected.(type) {
+		case *SingleFetch:
+			preparedInput := r.getBufPair()
+			err = r.prepareSingleFetch(ctx, f, data, set, preparedInput.Data)
+			if err != nil {
+				return err
+			}
+			*preparedInputs = append(*preparedInputs, preparedInput)
+			buf := set.buffers[f.BufferId]
+			bufs = append(bufs, buf)
+			errorExtensions = append(errorExtensions, f.errorExtensions())
+			resolvers = append(resolvers, func() error {
+				return r.resolveSingleFetch(fetchCtx, f, preparedInput.Data, buf)
+			})
+		case *BatchFetch:
+			preparedInput := r.getBufPair()
+			err = r.prepareSingleFetch(ctx, f.Fetch, data, set, preparedInput.Data)
+			if err != nil {
+				return err
+			}
+			*preparedInputs = append(*preparedInputs, preparedInput)
+			buf := set.buffers[f.Fetch.BufferId]
+			bufs = append(bufs, buf)
+			errorExtensions = append(errorExtensions, f.Fetch.errorExtensions())
+			resolvers = append(resolvers, func() error {
+				return r.resolveBatchFetch(fetchCtx, f, preparedInput.Data, buf)
+			})
+		case *RaceFetch:
+			raceInputs, err := r.prepareRaceFetch(ctx, f, data, set)
+			defer r.freeRaceInputs(raceInputs)
+			if err != nil {
+				return err
+			}
+			buf := set.buffers[f.BufferId]
+			bufs = append(bufs, buf)
+			errorExtensions = append(errorExtensions, nil)
+			resolvers = append(resolvers, func() error {
+				return r.raceFetches(fetchCtx, f, raceInputs, buf)
+			})
+		}
+	}
+
+	var (
+		failFastErr  error
+		failFastOnce sync.Once
+	)
+	// all inputs are prepared before the first fetch is started, so a failing input can't leave the WaitGroup with a nonzero counter
+	wg.Add(len(resolvers))
+	errs := make([]error, len(resolvers))
+	for i, resolver := range resolvers {
+		go func(i int, resolve func() error) {
+			defer wg.Done()
+			errs[i] = resolve()
+			var fetchErr *fetchFailedError
+			if errors.As(errs[i], &fetchErr) {
+				// fetches failing because of the cancellation must not replace the error which caused it
+				failFastOnce.Do(func() {
+					failFastErr = errs[i]
+					cancel()
+				})
+			}
+		}(i, resolver)
+	}
+
+	wg.Wait()
+
+	for i := range errs {
+		if errors.Is(errs[i], ErrTooManyFetches) {
+			return errs[i]
+		}
+	}
+
+	// fetch errors are recorded in the buffers, except for ErrorModeFailFast aborting with the first failed fetch
+	if failFastErr != nil {
+		return failFastErr
+	}
+
+	// a cancelled request fails every fetch, the cancellation is returned instead of an error per fetch
+	if ctxErr := ctx.Context.Err(); ctxErr != nil {
+		for i := range errs {
+			if errs[i] != nil {
+				return ctxErr
+			}
+		}
+	}
+
+	// the errors of failed fetches are recorded in their buffers and merged into the response with the fetched data
+	failed := make([]string, 0, len(errs))
+	for i := range errs {
+		if errs[i] == nil {
+			continue
+		}
+		bufs[i].WriteErr(escapeErrorMessage(errs[i].Error()), nil, nil, errorExtensions[i])
+		failed = append(failed, errs[i].Error())
+	}
+	if len(failed) != 0 && len(failed) == len(errs) {
+		return fmt.Errorf("%w: %s", ErrParallelFetchFailed, strings.Join(failed, ", "))
+	}
+
+	return
+}
+
+func (r *Resolver) prepareSingleFetch(ctx *Context, fetch *SingleFetch, data []byte, set *resultSet, preparedInput *fastbuffer.FastBuffer) (err error) {
+	err = fetch.InputTemplate.Render(ctx, data, preparedInput)
+	set.addBuffer(fetch.BufferId, r.getBufPair())
+	if err == nil {
+		err = checkFetchInputSize(fetch, preparedInput)
+	}
+	return
+}
+
+func checkFetchInputSize(fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer) error {
+	if fetch.MaxInputSize > 0 && preparedInput.Len() > fetch.MaxInputSize {
+		return fmt.Errorf("%w: %d bytes exceed the limit of %d bytes", ErrFetchInputTooLarge, preparedInput.Len(), fetch.MaxInputSize)
+	}
+	return nil
+}
+
+// resolveRaceFetch executes the fetches of a RaceFetch concurrently, the first successful fetch cancels the others.
+// It waits for the cancelled fetches to return, as their buffers are returned to the pool.
+func (r *Resolver) resolveRaceFetch(ctx *Context, fetch *RaceFetch, data []byte, set *resultSet) error {
+	preparedInputs, err := r.prepareRaceFetch(ctx, fetch, data, set)
+	defer r.freeRaceInputs(preparedInputs)
+	if err != nil {
+		return err
+	}
+	return r.raceFetches(ctx, fetch, preparedInputs, set.buffers[fetch.BufferId])
+}
+
+// prepareRaceFetch renders the inputs of all fetches of a RaceFetch and adds its buffer to the set,
+// the inputs must be freed with freeRaceInputs
+func (r *Resolver) prepareRaceFetch(ctx *Context, fetch *RaceFetch, data []byte, set *resultSet) ([]*BufPair, error) {
+	set.addBuffer(fetch.BufferId, r.getBufPair())
+	preparedInputs := make([]*BufPair, 0, len(fetch.Fetches))
+	for _, single := range fetch.Fetches {
+		preparedInput := r.getBufPair()
+		preparedInputs = append(preparedInputs, preparedInput)
+		if err := single.InputTemplate.Render(ctx, data, preparedInput.Data); err != nil {
+			return preparedInputs, err
+		}
+		if err := checkFetchInputSize(single, preparedInput.Data); err != nil {
+			return preparedInputs, err
+		}
+	}
+	return preparedInputs, nil
+}
+
+func (r *Resolver) freeRaceInputs(preparedInputs []*BufPair) {
+	for i := range preparedInputs {
+		r.freeBufPair(preparedInputs[i])
+	}
+}
+
+// raceFetches executes the fetches of a RaceFetch with their prepared inputs and writes the result of the winner to buf.
+// It doesn't touch the result set, so it's safe to call it concurrently with other fetches, e.g. within a ParallelFetch.
+func (r *Resolver) raceFetches(ctx *Context, fetch *RaceFetch, preparedInputs []*BufPair, buf *BufPair) error {
+	raceCtx := *ctx
+	var cancel context.CancelFunc
+	raceCtx.Context, cancel = context.WithCancel(ctx.Context)
+	defer cancel()
+
+	bufs := make([]*BufPair, len(fetch.Fetches))
+	for i := range bufs {
+		bufs[i] = r.getBufPair()
+	}
+	defer func() {
+		for i := range bufs {
+			r.freeBufPair(bufs[i])
+		}
+	}()
+
+	type raceResult struct {
+		index int
+		err   error
+	}
+	results := make(chan raceResult, len(fetch.Fetches))
+	for i, single := range fetch.Fetches {
+		go func(i int, single *SingleFetch) {
+			results <- raceResult{index: i, err: r.resolveSingleFetch(&raceCtx, single, preparedInputs[i].Data, bufs[i])}
+		}(i, single)
+	}
+
+	var (
+		winner = -1
+		last   raceResult
+	)
+	for range fetch.Fetches {
+		last = <-results
+		if winner != -1 || last.err != nil {
+			continue
+		}
+		result := bufs[last.index]
+		if result.HasData() && !result.HasErrors() && !bytes.Equal(result.Data.Bytes(), literal.NULL) {
+			winner = last.index
+			cancel()
+		}
+	}
+
+	if winner == -1 {
+		if ctxErr := ctx.Context.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if last.err != nil {
+			return last.err
+		}
+		winner = last.index
+	}
+
+	buf.Data.WriteBytes(bufs[winner].Data.Bytes())
+	buf.Errors.WriteBytes(bufs[winner].Errors.Bytes())
+	return nil
+}
+
+func (r *Resolver) resolveBatchFetch(ctx *Context, fetch *BatchFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) (err error) {
+	if fetch.Fetch.MaxAge > 0 && ctx.cacheControl != nil {
+		ctx.cacheControl.recordMaxAge(fetch.Fetch.MaxAge)
+	}
+
+	if ctx.fetchData != nil {
+		return ctx.writeFetchData(fetch.Fetch, buf)
+	}
+
+	if err = r.countFetch(ctx); err != nil {
+		return err
+	}
+	if err = r.startFetch(); err != nil {
+		return err
+	}
+	defer r.activeFetches.Done()
+	if ctx.stats != nil {
+		defer ctx.stats.observeFetch(time.Now())
+	}
+	if ctx.tracing != nil {
+		defer ctx.tracing.observeFetch(tracingPath(ctx.pathElements), time.Now())
+	}
 
-func (r *Resolver) freeResultSet(set *resultSet) {
-	for i := range set.buffers {
-		set.buffers[i].Reset()
-		r.bufPairPool.Put(set.buffers[i])
-		delete(set.buffers, i)
+	if r.metrics != nil {
+		defer r.observeFetch(fetch.Fetch, buf, &err)
 	}
-	r.resultSetPool.Put(set)
+	if r.fetchErrorMode == ErrorModeFailFast {
+		defer r.failFast(fetch.Fetch, buf, &err)
+	}
+	if r.log != nil {
+		defer r.logFetchFailure(fetch.Fetch, preparedInput.Bytes(), &err)
+	}
+
+	if r.dataLoaderEnabled && !fetch.Fetch.InputTemplate.rendersIndexVariable() {
+		err = ctx.dataLoader.LoadBatch(ctx, fetch, buf)
+	} else {
+		err = r.fetcher.FetchBatch(ctx, fetch, []*fastbuffer.FastBuffer{preparedInput}, []*BufPair{buf})
+	}
+	if err != nil {
+		return fetch.Fetch.wrapError(err)
+	}
+	fetch.Fetch.addServiceNameToErrors(buf)
+	return nil
 }
 
-func (r *Resolver) resolveFetch(ctx *Context, fetch Fetch, data []byte, set *resultSet) (err error) {
+func (r *Resolver) resolveSingleFetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) (err error) {
+	if fetch.MaxAge > 0 && ctx.cacheControl != nil {
+		ctx.cacheControl.recordMaxAge(fetch.MaxAge)
+	}
 
-	switch f := fetch.(type) {
-	case *SingleFetch:
-		preparedInput := r.getBufPair()
-		defer r.freeBufPair(preparedInput)
-		err = r.prepareSingleFetch(ctx, f, data, set, preparedInput.Data)
-		if err != nil {
-			return err
-		}
-		err = r.resolveSingleFetch(ctx, f, preparedInput.Data, set.buffers[f.BufferId])
-	case *BatchFetch:
-		preparedInput := r.getBufPair()
-		defer r.freeBufPair(preparedInput)
-		err = r.prepareSingleFetch(ctx, f.Fetch, data, set, preparedInput.Data)
-		if err != nil {
+	if ctx.fetchData != nil {
+		if err = ctx.writeFetchData(fetch, buf); err != nil {
 			return err
 		}
-		err = r.resolveBatchFetch(ctx, f, preparedInput.Data, set.buffers[f.Fetch.BufferId])
-	case *ParallelFetch:
-		err = r.resolveParallelFetch(ctx, f, data, set)
+		return r.postProcess(fetch, buf)
 	}
-	return
-}
 
-func (r *Resolver) resolveParallelFetch(ctx *Context, fetch *ParallelFetch, data []byte, set *resultSet) (err error) {
-	preparedInputs := r.getBufPairSlice()
-	defer r.freeBufPairSlice(preparedInputs)
+	useDataLoader := r.dataLoaderEnabled && !fetch.DisableDataLoader && !fetch.InputTemplate.rendersIndexVariable()
+	cache := ctx.arrayFetchCache
+	if useDataLoader || fetch.DisallowSingleFlight || len(ctx.Files) != 0 {
+		// like single flight, items only share a fetch if it may be deduplicated
+		cache = nil
+	}
+	if cache != nil && cache.load(fetch, preparedInput.Bytes(), buf) {
+		return r.postProcess(fetch, buf)
+	}
 
-	resolvers := make([]func() error, 0, len(fetch.Fetches))
+	if err = r.countFetch(ctx); err != nil {
+		return err
+	}
+	if err = r.startFetch(); err != nil {
+		return err
+	}
+	defer r.activeFetches.Done()
+	if ctx.stats != nil {
+		defer ctx.stats.observeFetch(time.Now())
+	}
+	if ctx.tracing != nil {
+		defer ctx.tracing.observeFetch(tracingPath(ctx.pathElements), time.Now())
+	}
 
-	wg := r.getWaitGroup()
-	defer r.freeWaitGroup(wg)
+	if r.metrics != nil {
+		defer r.observeFetch(fetch, buf, &err)
+	}
+	if r.fetchErrorMode == ErrorModeFailFast {
+		defer r.failFast(fetch, buf, &err)
+	}
+	if r.staleFetches != nil {
+		defer r.serveStaleOnError(ctx, fetch, preparedInput.Bytes(), buf, &err)
+	}
+	if r.log != nil {
+		defer r.logFetchFailure(fetch, preparedInput.Bytes(), &err)
+	}
 
-	for i := range fetch.Fetches {
-		wg.Add(1)
-		switch f := fetch.Fetches[i].(type) {
-		case *SingleFetch:
-			preparedInput := r.getBufPair()
-			err = r.prepareSingleFetch(ctx, f, data, set, preparedInput.Data)
-			if err != nil {
-				return err
-			}
-			*preparedInputs = append(*preparedInputs, preparedInput)
-			buf := set.buffers[f.BufferId]
-			resolvers = append(resolvers, func() error {
-				return r.resolveSingleFetch(ctx, f, preparedInput.Data, buf)
-			})
-		case *BatchFetch:
-			preparedInput := r.getBufPair()
-			err = r.prepareSingleFetch(ctx, f.Fetch, data, set, preparedInput.Data)
-			if err != nil {
-				return err
-			}
-			*preparedInputs = append(*preparedInputs, preparedInput)
-			buf := set.buffers[f.Fetch.BufferId]
-			resolvers = append(resolvers, func() error {
-				return r.resolveBatchFetch(ctx, f, preparedInput.Data, buf)
-			})
-		}
+	if useDataLoader {
+		err = ctx.dataLoader.Load(ctx, fetch, buf)
+	} else {
+		err = r.fetcher.Fetch(ctx, fetch, preparedInput, buf)
+	}
+	if err != nil {
+		return fetch.wrapError(err)
+	}
+	fetch.addServiceNameToErrors(buf)
+	if cache != nil {
+		cache.store(fetch, preparedInput.Bytes(), buf)
 	}
+	if r.staleFetches != nil && !buf.HasErrors() {
+		r.staleFetches.store(fetch, preparedInput.Bytes(), buf)
+	}
+	return r.postProcess(fetch, buf)
+}
 
-	for _, resolver := range resolvers {
-		go func(r func() error) {
-			_ = r()
-			wg.Done()
-		}(resolver)
+func (r *Resolver) postProcess(fetch *SingleFetch, buf *BufPair) error {
+	if fetch.PostProcess == nil || !buf.HasData() {
+		return nil
 	}
 
-	wg.Wait()
+	// buf is owned by the current request, so the result shared between single flight waiters is never modified
+	processed, err := fetch.PostProcess(buf.Data.Bytes())
+	if err != nil {
+		return err
+	}
+	buf.Data.Reset()
+	buf.Data.WriteBytes(processed)
+	return nil
+}
 
-	return
+// arrayFetchCache holds the results of the fetches of the items of a synchronously resolved array,
+// so items with identical fetch inputs, e.g. many posts by the same author, result in a single fetch.
+// Fetches of an item may be resolved concurrently, e.g. using a ParallelFetch.
+type arrayFetchCache struct {
+	mu      sync.Mutex
+	entries map[arrayFetchKey]*arrayFetchResult
 }
 
-func (r *Resolver) prepareSingleFetch(ctx *Context, fetch *SingleFetch, data []byte, set *resultSet, preparedInput *fastbuffer.FastBuffer) (err error) {
-	err = fetch.InputTemplate.Render(ctx, data, preparedInput)
-	buf := r.getBufPair()
-	set.buffers[fetch.BufferId] = buf
-	return
+type arrayFetchKey struct {
+	fetch *SingleFetch
+	input string
 }
 
-func (r *Resolver) resolveBatchFetch(ctx *Context, fetch *BatchFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) error {
-	if r.dataLoaderEnabled {
-		return ctx.dataLoader.LoadBatch(ctx, fetch, buf)
+type arrayFetchResult struct {
+	data   []byte
+	errors []byte
+}
+
+func (c *arrayFetchCache) load(fetch *SingleFetch, input []byte, buf *BufPair) bool {
+	c.mu.Lock()
+	result, ok := c.entries[arrayFetchKey{fetch: fetch, input: string(input)}]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	buf.Data.WriteBytes(result.data)
+	buf.Errors.WriteBytes(result.errors)
+	return true
+}
+
+func (c *arrayFetchCache) store(fetch *SingleFetch, input []byte, buf *BufPair) {
+	result := &arrayFetchResult{
+		data:   make([]byte, buf.Data.Len()),
+		errors: make([]byte, buf.Errors.Len()),
 	}
+	copy(result.data, buf.Data.Bytes())
+	copy(result.errors, buf.Errors.Bytes())
+	c.mu.Lock()
+	c.entries[arrayFetchKey{fetch: fetch, input: string(input)}] = result
+	c.mu.Unlock()
+}
 
-	if err := r.fetcher.FetchBatch(ctx, fetch, []*fastbuffer.FastBuffer{preparedInput}, []*BufPair{buf}); err != nil {
-		return err
+// failFast turns a failed fetch into a fetchFailedError aborting the response
+func (r *Resolver) failFast(fetch *SingleFetch, buf *BufPair, err *error) {
+	switch {
+	case *err != nil:
+		errorObject := r.getBufPair()
+		defer r.freeBufPair(errorObject)
+		errorObject.WriteErr(escapeErrorMessage((*err).Error()), nil, nil, fetch.errorExtensions())
+		errs := make([]byte, errorObject.Errors.Len())
+		copy(errs, errorObject.Errors.Bytes())
+		*err = &fetchFailedError{errors: errs}
+	case buf.HasErrors():
+		errs := make([]byte, buf.Errors.Len())
+		copy(errs, buf.Errors.Bytes())
+		*err = &fetchFailedError{errors: errs}
 	}
+}
 
-	return nil
+// logFetchFailure logs the error of a failed fetch, it must be deferred after failFast to log the original error
+func (r *Resolver) logFetchFailure(fetch *SingleFetch, input []byte, err *error) {
+	if *err == nil {
+		return
+	}
+	inputField := abstractlogger.String("input", string(input))
+	if r.redactFetchInput {
+		inputField = abstractlogger.String("input", "[redacted]")
+	}
+	r.log.Error("resolve.Resolver.resolveFetch: fetch failed",
+		abstractlogger.ByteString("dataSource", fetch.DataSourceIdentifier),
+		inputField,
+		abstractlogger.Error(*err),
+	)
 }
 
-func (r *Resolver) resolveSingleFetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) error {
-	if r.dataLoaderEnabled && !fetch.DisableDataLoader {
-		return ctx.dataLoader.Load(ctx, fetch, buf)
+func (r *Resolver) observeFetch(fetch *SingleFetch, buf *BufPair, err *error) {
+	r.metrics.IncFetch(fetch.DataSourceIdentifier)
+	if *err != nil || buf.HasErrors() {
+		r.metrics.IncFetchError(fetch.DataSourceIdentifier)
 	}
-	return r.fetcher.Fetch(ctx, fetch, preparedInput, buf)
 }
 
+// Object resolves its Fields from the data at Path. Fetch loads the data of the fields with a buffer before they are resolved,
+// fields of different data sources, e.g. root fields of stitched services, are loaded by a ParallelFetch
+// and each field reads the data of the fetch with the BufferId equal to its BufferID.
 type Object struct {
 	Nullable             bool
 	Path                 []string
 	Fields               []*Field
 	Fetch                Fetch
 	UnescapeResponseJson bool `json:"unescape_response_json,omitempty"`
+	// KeyPath resolves the object from a fetch result keyed by a correlation id, e.g. {"1":{...},"2":{...}}.
+	// The object is looked up in the data at Path using the value at KeyPath of the parent object as key.
+	// It's used for fields with a buffer, so the result of a single batch fetch can be scattered to many parents.
+	KeyPath []string `json:"key_path,omitempty"`
+	// TypeDiscriminator determines the type name for the Field.OnTypeName conditions of the fields
+	// for backends which don't return __typename, by default the type name is read from __typename.
+	TypeDiscriminator *TypeDiscriminator `json:"type_discriminator,omitempty"`
+}
+
+// TypeDiscriminator maps the value of a discriminator field, e.g. {"kind":"image"}, to a type name.
+type TypeDiscriminator struct {
+	// Path of the discriminator value, e.g. []string{"kind"}, the value can be a string or a number
+	Path []string
+	// TypeNames maps each discriminator value to its type name, e.g. "image" to "Image".
+	// Objects with an unknown discriminator value match no type.
+	TypeNames map[string]string
+}
+
+func (d *TypeDiscriminator) typeName(json JSONAccessor, data []byte) []byte {
+	value, dataType, _, err := json.Get(data, d.Path...)
+	if err != nil || (dataType != jsonparser.String && dataType != jsonparser.Number) {
+		return nil
+	}
+	typeName, ok := d.TypeNames[string(value)]
+	if !ok {
+		return nil
+	}
+	return unsafebytes.StringToBytes(typeName)
 }
 
 func (_ *Object) NodeKind() NodeKind {
@@ -1299,6 +3552,8 @@ func (_ *EmptyArray) NodeKind() NodeKind {
 }
 
 type Field struct {
+	// Name is the key of the field in the response, which is the alias if the field is aliased.
+	// The value is read from the path of Value, so the same data can be written under several aliases.
 	Name                    []byte
 	Value                   Node
 	Position                Position
@@ -1311,6 +3566,14 @@ type Field struct {
 	SkipVariableName        string
 	IncludeDirectiveDefined bool
 	IncludeVariableName     string
+	// Deduplicate is set by the planner for a leaf field sharing its response key with a previous leaf field of the object,
+	// e.g. { ... on Droid { name } name }. The field is omitted if the previous field has been written.
+	Deduplicate bool
+	// NoCache marks fields which must not be cached, e.g. me or viewer, so the whole response is not cacheable
+	NoCache bool
+	// Timeout limits the time to resolve the value of the field including its nested fetches, 0 uses the default of the Resolver.
+	// A field exceeding its timeout resolves to null with an error, see WithFieldTimeout.
+	Timeout time.Duration `json:"-"`
 }
 
 type Position struct {
@@ -1339,6 +3602,19 @@ func (_ *Null) NodeKind() NodeKind {
 
 type resultSet struct {
 	buffers map[int]*BufPair
+	// bufferIDs keeps the ids of all buffers in ascending order,
+	// it's used to merge buffers in a stable order, independent of the order in which fetches complete
+	bufferIDs []int
+}
+
+func (r *resultSet) addBuffer(id int, buf *BufPair) {
+	if _, exists := r.buffers[id]; !exists {
+		i := sort.SearchInts(r.bufferIDs, id)
+		r.bufferIDs = append(r.bufferIDs, 0)
+		copy(r.bufferIDs[i+1:], r.bufferIDs[i:])
+		r.bufferIDs[i] = id
+	}
+	r.buffers[id] = buf
 }
 
 type SingleFetch struct {
@@ -1350,11 +3626,80 @@ type SingleFetch struct {
 	// By default SingleFlight for fetches is disabled and needs to be enabled on the Resolver first
 	// If the resolver allows SingleFlight it's up the each individual DataSource Planner to decide whether an Operation
 	// should be allowed to use SingleFlight
-	DisallowSingleFlight  bool
-	DisableDataLoader     bool
-	InputTemplate         InputTemplate
-	DataSourceIdentifier  []byte
-	ProcessResponseConfig ProcessResponseConfig
+	DisallowSingleFlight bool
+	// SingleFlightIgnoredInputPaths are JSON paths into the input which are not considered when deduplicating fetches,
+	// e.g. a per request nonce or timestamp which doesn't affect the response.
+	SingleFlightIgnoredInputPaths [][]string
+	DisableDataLoader             bool
+	InputTemplate                 InputTemplate
+	DataSourceIdentifier          []byte
+	ProcessResponseConfig         ProcessResponseConfig
+	// CacheKey optionally replaces the hash of the input used to deduplicate fetches,
+	// e.g. to coalesce semantically equal inputs with a different order of fields.
+	// SingleFlightIgnoredInputPaths are removed from the input before CacheKey is called.
+	CacheKey func(input []byte) uint64 `json:"-"`
+	// PostProcess is optional and transforms the data of the response before fields are resolved from it,
+	// e.g. to unwrap an envelope. The returned slice may point into the passed data.
+	PostProcess func(data []byte) ([]byte, error) `json:"-"`
+	// MaxInputSize optionally limits the size of the rendered input in bytes, e.g. to guard against huge lists of ids.
+	// Exceeding inputs fail with ErrFetchInputTooLarge before the DataSource is called.
+	MaxInputSize int `json:"max_input_size,omitempty"`
+	// MaxAge is an optional cache hint, the duration the response of the DataSource may be cached, see CacheControl
+	MaxAge time.Duration `json:"max_age,omitempty"`
+	// ServiceName optionally identifies the backend of the fetch, the error written for a failed fetch
+	// and the errors returned by the backend carry it as extensions.serviceName so clients and operators can tell which backend failed.
+	// An error which isn't written to the response is returned as FetchError.
+	ServiceName string `json:"service_name,omitempty"`
+}
+
+// errorExtensions returns the extensions of the error written for a failed fetch, nil without a ServiceName
+func (f *SingleFetch) errorExtensions() []byte {
+	if f.ServiceName == "" {
+		return nil
+	}
+	serviceName, _ := json.Marshal(f.ServiceName)
+	extensions := make([]byte, 0, len(serviceName)+16)
+	extensions = append(extensions, `{"serviceName":`...)
+	extensions = append(extensions, serviceName...)
+	return append(extensions, '}')
+}
+
+// wrapError returns err as FetchError if the fetch has a ServiceName
+func (f *SingleFetch) wrapError(err error) error {
+	if f.ServiceName == "" {
+		return err
+	}
+	return &FetchError{ServiceName: f.ServiceName, Err: err}
+}
+
+// addServiceNameToErrors adds extensions.serviceName to the errors returned by the backend of the fetch,
+// a service name set by the backend itself is kept
+func (f *SingleFetch) addServiceNameToErrors(buf *BufPair) {
+	if f.ServiceName == "" || !buf.HasErrors() {
+		return
+	}
+	serviceName, _ := json.Marshal(f.ServiceName)
+	// buf.Errors holds the comma separated errors, they are parsed as array
+	errs := make([]byte, 0, buf.Errors.Len()+2)
+	errs = append(errs, lBrack...)
+	errs = append(errs, buf.Errors.Bytes()...)
+	errs = append(errs, rBrack...)
+
+	buf.Errors.Reset()
+	first := true
+	_, _ = jsonparser.ArrayEach(errs, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if !first {
+			buf.Errors.WriteBytes(comma)
+		}
+		first = false
+		if _, _, _, err := jsonparser.Get(value, "extensions", "serviceName"); err != nil {
+			// value is copied as it points into errs
+			if annotated, err := jsonparser.Set(append([]byte(nil), value...), serviceName, "extensions", "serviceName"); err == nil {
+				value = annotated
+			}
+		}
+		buf.Errors.WriteBytes(value)
+	})
 }
 
 type ProcessResponseConfig struct {
@@ -1366,6 +3711,7 @@ func (_ *SingleFetch) FetchKind() FetchKind {
 	return FetchKindSingle
 }
 
+// ParallelFetch runs independent fetches concurrently, the data of each fetch is written to the buffer of its BufferId
 type ParallelFetch struct {
 	Fetches []Fetch
 }
@@ -1383,6 +3729,62 @@ func (_ *BatchFetch) FetchKind() FetchKind {
 	return FetchKindBatch
 }
 
+// RaceFetch executes its Fetches concurrently and resolves with the first fetch returning data without errors,
+// e.g. to fetch the same data from redundant backends for a better tail latency and availability.
+// Once a fetch succeeds, the other fetches are cancelled, so their DataSources should return as soon as their context is done.
+// The data of the winning fetch is written to BufferId, the BufferId of the Fetches is ignored.
+// If no fetch succeeds, the RaceFetch fails like the fetch which returned last.
+// The Fetches should disable the data loader, it keeps the results of fetches by their BufferId.
+type RaceFetch struct {
+	BufferId int
+	Fetches  []*SingleFetch
+}
+
+func (_ *RaceFetch) FetchKind() FetchKind {
+	return FetchKindRace
+}
+
+// TypeConditionalFetch selects the Fetch to execute by the concrete type of the object it is attached to.
+// This allows to resolve members of a union or implementations of an interface from distinct data sources.
+//
+// The concrete type name is read from the object data at TypeNamePath (defaults to "__typename").
+// Only the Fetch of the first matching condition gets executed, all other fetches are skipped.
+// If no condition matches, no fetch is executed at all.
+//
+// Buffer mapping: each condition's Fetch must use its own BufferId.
+// Fields that read from a type specific fetch set HasBuffer & BufferID to the BufferId of that fetch
+// and OnTypeName to the type name of the condition.
+// As only the selected fetch writes to its buffer, fields of all other types find no buffer and are skipped by their OnTypeName condition.
+// For the selected type, OnTypeName is evaluated against the response of the fetch,
+// so the data source response has to contain the __typename field.
+// Type specific fetches should disable the data loader, because siblings of an array might have different types.
+type TypeConditionalFetch struct {
+	TypeNamePath []string
+	Conditions   []TypeCondition
+}
+
+type TypeCondition struct {
+	TypeName []byte
+	Fetch    Fetch
+}
+
+func (t *TypeConditionalFetch) selectFetch(json JSONAccessor, data []byte) Fetch {
+	typeName := typeNameFromData(json, data, t.TypeNamePath)
+	if typeName == nil {
+		return nil
+	}
+	for i := range t.Conditions {
+		if bytes.Equal(t.Conditions[i].TypeName, typeName) {
+			return t.Conditions[i].Fetch
+		}
+	}
+	return nil
+}
+
+func (_ *TypeConditionalFetch) FetchKind() FetchKind {
+	return FetchKindTypeConditional
+}
+
 // FieldExport takes the value of the field during evaluation (rendering of the field)
 // and stores it in the variables using the Path as JSON pointer.
 type FieldExport struct {
@@ -1392,20 +3794,59 @@ type FieldExport struct {
 
 type String struct {
 	Path                 []string
+	FallbackPaths        [][]string `json:"fallback_paths,omitempty"`
 	Nullable             bool
 	Export               *FieldExport `json:"export,omitempty"`
 	UnescapeResponseJson bool         `json:"unescape_response_json,omitempty"`
 	IsTypeName           bool         `json:"is_type_name,omitempty"`
+	// NullValue is written instead of null if the value of a nullable node is null or missing, e.g. to keep a distinct empty marker.
+	// It must be valid JSON, a string has to be passed including its quotes.
+	NullValue []byte `json:"null_value,omitempty"`
+	// Transforms are applied in order to the resolved value before it is written, e.g. for @uppercase
+	Transforms []ScalarTransform `json:"-"`
 }
 
 func (_ *String) NodeKind() NodeKind {
 	return NodeKindString
 }
 
-type Boolean struct {
+// TypeName resolves the __typename of an object.
+// For concrete object types the name is known upfront and set as Value.
+// For abstract types (interfaces, unions) Value is nil and the concrete type name is read from the data at Path,
+// which defaults to "__typename", the same source as the one used to evaluate Field.OnTypeName.
+type TypeName struct {
+	Value    []byte
 	Path     []string
 	Nullable bool
-	Export   *FieldExport `json:"export,omitempty"`
+}
+
+func (_ *TypeName) NodeKind() NodeKind {
+	return NodeKindTypeName
+}
+
+// JSONString reads the JSON value at Path and renders it as a JSON encoded string,
+// e.g. the object {"a":1} is rendered as "{\"a\":1}".
+type JSONString struct {
+	Path     []string
+	Nullable bool
+}
+
+func (_ *JSONString) NodeKind() NodeKind {
+	return NodeKindJSONString
+}
+
+type Boolean struct {
+	Path          []string
+	FallbackPaths [][]string `json:"fallback_paths,omitempty"`
+	Nullable      bool
+	Export        *FieldExport `json:"export,omitempty"`
+	// CoerceFromNumberOrString accepts booleans encoded as 0/1 or "false"/"true", for backends not returning JSON booleans.
+	// Other numbers and strings are treated like any other value of an unexpected type.
+	CoerceFromNumberOrString bool `json:"coerce_from_number_or_string,omitempty"`
+	// NullValue is written instead of null, see String.NullValue
+	NullValue []byte `json:"null_value,omitempty"`
+	// Transforms are applied to the resolved value, see String.Transforms
+	Transforms []ScalarTransform `json:"-"`
 }
 
 func (_ *Boolean) NodeKind() NodeKind {
@@ -1413,9 +3854,17 @@ func (_ *Boolean) NodeKind() NodeKind {
 }
 
 type Float struct {
-	Path     []string
-	Nullable bool
-	Export   *FieldExport `json:"export,omitempty"`
+	Path          []string
+	FallbackPaths [][]string `json:"fallback_paths,omitempty"`
+	Nullable      bool
+	Export        *FieldExport `json:"export,omitempty"`
+	// CoerceFromString accepts numbers encoded as JSON string, e.g. "4.2", for backends not returning JSON numbers.
+	// Strings which aren't a valid number are treated like any other value of an unexpected type.
+	CoerceFromString bool `json:"coerce_from_string,omitempty"`
+	// NullValue is written instead of null, see String.NullValue
+	NullValue []byte `json:"null_value,omitempty"`
+	// Transforms are applied to the resolved value, see String.Transforms
+	Transforms []ScalarTransform `json:"-"`
 }
 
 func (_ *Float) NodeKind() NodeKind {
@@ -1423,22 +3872,53 @@ func (_ *Float) NodeKind() NodeKind {
 }
 
 type Integer struct {
-	Path     []string
-	Nullable bool
-	Export   *FieldExport `json:"export,omitempty"`
+	Path          []string
+	FallbackPaths [][]string `json:"fallback_paths,omitempty"`
+	Nullable      bool
+	Export        *FieldExport `json:"export,omitempty"`
+	// CoerceFromString accepts integers encoded as JSON string, e.g. "42", for backends not returning JSON numbers.
+	// Strings which aren't a valid integer are treated like any other value of an unexpected type.
+	CoerceFromString bool `json:"coerce_from_string,omitempty"`
+	// NullValue is written instead of null, see String.NullValue
+	NullValue []byte `json:"null_value,omitempty"`
+	// Transforms are applied to the resolved value, see String.Transforms
+	Transforms []ScalarTransform `json:"-"`
 }
 
 func (_ *Integer) NodeKind() NodeKind {
 	return NodeKindInteger
 }
 
+// StaticValue is a node that is resolved to a constant value without reading any data, e.g. a fixed "apiVersion".
+// Value must be valid JSON, a string has to be passed including its quotes.
+type StaticValue struct {
+	Value []byte
+}
+
+func (_ *StaticValue) NodeKind() NodeKind {
+	return NodeKindStaticValue
+}
+
+// Array resolves a list by resolving Item for each item of the JSON array at Path.
+// Paths of scalars can't descend into the items of a list, a list nested in the data is modeled by an Array
+// whose Path ends at the list and an Item whose paths are relative to each item, e.g. for {"edges":[{"node":{"name":"a"}}]}:
+//
+//	&Array{Path: []string{"edges"}, Item: &Object{Fields: []*Field{{Name: []byte("name"), Value: &String{Path: []string{"node", "name"}}}}}}
+//
+// A scalar with the path ["edges","node","name"] resolves to null with an ErrPathCrossesArray error instead of a silent null.
 type Array struct {
-	Path                 []string
+	Path []string
+	// Nullable is the nullability of the list itself, the nullability of its items is defined by Item,
+	// e.g. [T]! is a non-nullable Array of a nullable Item
 	Nullable             bool
 	ResolveAsynchronous  bool
 	Item                 Node
 	Stream               Stream
 	UnescapeResponseJson bool `json:"unescape_response_json,omitempty"`
+	// SkipNullItems omits items resolving to null instead of rendering them, e.g. deleted entries of a list of nullable items
+	SkipNullItems bool `json:"skip_null_items,omitempty"`
+	// sizeHint is the number of items of the last resolved list, see WithAdaptiveArrayCapacity
+	sizeHint int32
 }
 
 type Stream struct {
@@ -1451,6 +3931,23 @@ func (_ *Array) NodeKind() NodeKind {
 	return NodeKindArray
 }
 
+// ScalarArray resolves a list of scalars, e.g. [Int] or [String!]!, by copying the JSON array at Path as a whole
+// instead of resolving an Item node per element, which is faster for large lists.
+// Each element must be of the JSON type of ItemKind, or null if ItemNullable is set.
+// Elements of another type are resolved to null for nullable items, like an Array of scalar Item nodes would do,
+// for non-nullable items the list is resolved to null, or fails if it's not Nullable.
+type ScalarArray struct {
+	Path     []string
+	Nullable bool
+	// ItemKind is the kind of the scalar items, one of NodeKindString, NodeKindBoolean, NodeKindInteger and NodeKindFloat
+	ItemKind     NodeKind
+	ItemNullable bool
+}
+
+func (_ *ScalarArray) NodeKind() NodeKind {
+	return NodeKindScalarArray
+}
+
 type GraphQLSubscription struct {
 	Trigger  GraphQLSubscriptionTrigger
 	Response *GraphQLResponse
@@ -1468,9 +3965,18 @@ type FlushWriter interface {
 	Flush()
 }
 
+// HeartbeatWriter is optionally implemented by the FlushWriter of a subscription whose framing wraps each flush as update,
+// WriteHeartbeat writes and flushes a heartbeat in the framing of the writer instead, see Context.SetSubscriptionHeartbeat.
+type HeartbeatWriter interface {
+	WriteHeartbeat() error
+}
+
 type GraphQLResponse struct {
 	Data            Node
 	RenameTypeNames []RenameTypeName
+	// Extensions is optional, it's rendered as the top level "extensions" object of the response, e.g. for tracing or caching hints.
+	// It's resolved using the same root data as Data.
+	Extensions Node
 }
 
 type RenameTypeName struct {
@@ -1614,6 +4120,17 @@ func (r *Resolver) freeBufPairSlice(slice *[]*BufPair) {
 	r.bufPairSlicePool.Put(slice)
 }
 
+func (r *Resolver) getArrayFetchCache() *arrayFetchCache {
+	return r.arrayFetchCaches.Get().(*arrayFetchCache)
+}
+
+func (r *Resolver) freeArrayFetchCache(cache *arrayFetchCache) {
+	for key := range cache.entries {
+		delete(cache.entries, key)
+	}
+	r.arrayFetchCaches.Put(cache)
+}
+
 func (r *Resolver) getErrChan() chan error {
 	return r.errChanPool.Get().(chan error)
 }
@@ -1626,11 +4143,79 @@ func (r *Resolver) getWaitGroup() *sync.WaitGroup {
 	return r.waitGroupPool.Get().(*sync.WaitGroup)
 }
 
+// freeWaitGroup must only be called once the counter of wg is back to zero, otherwise the next user of wg blocks forever
 func (r *Resolver) freeWaitGroup(wg *sync.WaitGroup) {
 	r.waitGroupPool.Put(wg)
 }
 
-func writeGraphqlResponse(buf *BufPair, writer io.Writer, ignoreData bool) (err error) {
+// writePrettyGraphqlResponse writes the response indented by two spaces, chunked flushing is not supported
+func writePrettyGraphqlResponse(buf *BufPair, extensions []byte, writer io.Writer, ignoreData bool) (err error) {
+	compact := pool.BytesBuffer.Get()
+	defer pool.BytesBuffer.Put(compact)
+	if err = writeGraphqlResponse(buf, extensions, compact, ignoreData); err != nil {
+		return err
+	}
+
+	return writeIndented(compact.Bytes(), writer)
+}
+
+func writeIndented(response []byte, writer io.Writer) error {
+	indented := pool.BytesBuffer.Get()
+	defer pool.BytesBuffer.Put(indented)
+	if err := json.Indent(indented, response, "", "  "); err != nil {
+		return err
+	}
+	_, err := writer.Write(indented.Bytes())
+	return err
+}
+
+// writeBufferedGraphqlResponse writes the complete response into a buffer first to apply the response envelope and transform
+func (r *Resolver) writeBufferedGraphqlResponse(transform ResponseTransform, buf *BufPair, extensions []byte, writer io.Writer, ignoreData bool) (err error) {
+	response := pool.BytesBuffer.Get()
+	defer pool.BytesBuffer.Put(response)
+	if r.responseEnvelope != nil {
+		err = r.writeEnvelopedGraphqlResponse(buf, extensions, response, ignoreData)
+	} else {
+		err = writeGraphqlResponse(buf, extensions, response, ignoreData)
+	}
+	if err != nil {
+		return err
+	}
+
+	result := response.Bytes()
+	if transform != nil {
+		if result, err = transform(result); err != nil {
+			return err
+		}
+	}
+
+	if r.prettyPrint {
+		return writeIndented(result, writer)
+	}
+	_, err = writer.Write(result)
+	return err
+}
+
+func (r *Resolver) writeEnvelopedGraphqlResponse(buf *BufPair, extensions []byte, writer io.Writer, ignoreData bool) error {
+	var data, errs []byte
+	if buf.Data.Len() != 0 && !ignoreData {
+		data = buf.Data.Bytes()
+	}
+	if buf.HasErrors() {
+		errorsArray := pool.BytesBuffer.Get()
+		defer pool.BytesBuffer.Put(errorsArray)
+		errorsArray.Write(lBrack)
+		errorsArray.Write(buf.Errors.Bytes())
+		errorsArray.Write(rBrack)
+		errs = errorsArray.Bytes()
+	}
+	if len(extensions) == 0 {
+		extensions = nil
+	}
+	return r.responseEnvelope(writer, data, errs, extensions)
+}
+
+func writeGraphqlResponse(buf *BufPair, extensions []byte, writer io.Writer, ignoreData bool) (err error) {
 	hasErrors := buf.Errors.Len() != 0
 	hasData := buf.Data.Len() != 0 && !ignoreData
 
@@ -1657,11 +4242,62 @@ func writeGraphqlResponse(buf *BufPair, writer io.Writer, ignoreData bool) (err
 	} else {
 		err = writeSafe(err, writer, literal.NULL)
 	}
+
+	if len(extensions) != 0 {
+		err = writeSafe(err, writer, comma)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, literalExtensions)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, colon)
+		err = writeSafe(err, writer, extensions)
+	}
+
+	err = writeSafe(err, writer, rBrace)
+
+	return err
+}
+
+// writeStreamedGraphqlResponseEnd writes the remainder of a response whose beginning has been written by a responseStream.
+// The errors follow the data as they're only complete once the data is resolved.
+func writeStreamedGraphqlResponseEnd(buf *BufPair, extensions []byte, writer io.Writer) (err error) {
+	err = writeSafe(err, writer, buf.Data.Bytes())
+
+	if buf.Errors.Len() != 0 {
+		err = writeSafe(err, writer, comma)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, literalErrors)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, colon)
+		err = writeSafe(err, writer, lBrack)
+		err = writeSafe(err, writer, buf.Errors.Bytes())
+		err = writeSafe(err, writer, rBrack)
+	}
+
+	if len(extensions) != 0 {
+		err = writeSafe(err, writer, comma)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, literalExtensions)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, colon)
+		err = writeSafe(err, writer, extensions)
+	}
+
 	err = writeSafe(err, writer, rBrace)
 
 	return err
 }
 
+type countingWriter struct {
+	writer io.Writer
+	n      int
+}
+
+func (c *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = c.writer.Write(p)
+	c.n += n
+	return
+}
+
 func writeSafe(err error, writer io.Writer, data []byte) error {
 	if err != nil {
 		return err
@@ -0,0 +1,135 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// fuzzNodeBuilder builds a node tree from the bytes of the fuzzer, so that arbitrary inputs result in valid trees
+type fuzzNodeBuilder struct {
+	shape []byte
+	pos   int
+}
+
+var fuzzFieldNames = []string{"a", "b", "id", "name", "__typename"}
+
+func (b *fuzzNodeBuilder) next() int {
+	if b.pos >= len(b.shape) {
+		return 0
+	}
+	next := int(b.shape[b.pos])
+	b.pos++
+	return next
+}
+
+func (b *fuzzNodeBuilder) path() []string {
+	if b.next()%3 == 0 {
+		return nil
+	}
+	return []string{fuzzFieldNames[b.next()%len(fuzzFieldNames)]}
+}
+
+func (b *fuzzNodeBuilder) node(depth int) Node {
+	kind := b.next() % 9
+	if depth > 3 && kind < 2 {
+		kind += 2
+	}
+	nullable := b.next()%2 == 0
+	switch kind {
+	case 0:
+		return b.object(depth, nullable)
+	case 1:
+		return &Array{
+			Path:                b.path(),
+			Nullable:            nullable,
+			ResolveAsynchronous: b.next()%2 == 0,
+			Item:                b.node(depth + 1),
+		}
+	case 2:
+		// UnescapeResponseJson isn't fuzzed, it's only planned for strings which contain JSON
+		return &String{Path: b.path(), Nullable: nullable}
+	case 3:
+		return &Integer{Path: b.path(), Nullable: nullable}
+	case 4:
+		return &Float{Path: b.path(), Nullable: nullable}
+	case 5:
+		return &Boolean{Path: b.path(), Nullable: nullable}
+	case 6:
+		return &JSONString{Path: b.path(), Nullable: nullable}
+	case 7:
+		return &EmptyArray{}
+	default:
+		return &Null{}
+	}
+}
+
+func (b *fuzzNodeBuilder) object(depth int, nullable bool) *Object {
+	object := &Object{
+		Path:     b.path(),
+		Nullable: nullable,
+	}
+	fields := b.next() % 4
+	for i := 0; i < fields; i++ {
+		field := &Field{
+			Name:  []byte(fuzzFieldNames[i]),
+			Value: b.node(depth + 1),
+		}
+		if b.next()%5 == 0 {
+			field.OnTypeName = []byte("User")
+		}
+		object.Fields = append(object.Fields, field)
+	}
+	return object
+}
+
+func FuzzResolver_ResolveGraphQLResponse(f *testing.F) {
+	seeds := []string{
+		`{}`,
+		`null`,
+		``,
+		`{"a":null}`,
+		`{"a":"\"escaped\" é"}`,
+		`{"a":[1,2,3],"b":[{"id":1},{"id":"2"},null]}`,
+		`{"a":{"b":{"a":{"b":{}}}}}`,
+		`{"name":"unterminated`,
+		`{"a":[1,2,`,
+		`[{"__typename":"User","id":1},{"__typename":"Admin"}]`,
+		`{"a":1e400,"b":-0,"id":9223372036854775808}`,
+		`{"a":tru,"b":nul}`,
+		`{"a":{"a":"}}"}}`,
+		"\x00\xff{\"a\":\"\xc3\x28\"}",
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed), []byte{0, 3, 1, 2, 1, 0, 1, 3, 2, 1, 4})
+		f.Add([]byte(seed), []byte{1, 1, 0, 1, 0, 2, 1, 1, 0, 5, 0, 3})
+	}
+
+	rCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := newResolver(rCtx, false, false)
+
+	f.Fuzz(func(t *testing.T, data []byte, shape []byte) {
+		builder := &fuzzNodeBuilder{shape: shape}
+		root := builder.object(0, builder.next()%2 == 0)
+		root.Fetch = &SingleFetch{
+			BufferId:   0,
+			DataSource: FakeDataSource(string(data)),
+		}
+		for i := range root.Fields {
+			root.Fields[i].HasBuffer = true
+			root.Fields[i].BufferID = 0
+		}
+
+		out := &bytes.Buffer{}
+		err := resolver.ResolveGraphQLResponse(NewContext(context.Background()), &GraphQLResponse{Data: root}, nil, out)
+		// the resolver doesn't validate the data of fetches, so malformed data must only not cause a panic
+		if err != nil || !json.Valid(data) {
+			return
+		}
+		if !json.Valid(out.Bytes()) {
+			t.Fatalf("invalid JSON response %q for data %q", out.String(), data)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package resolve
+
+import (
+	"io"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// ResponseEnvelope writes the top-level framing of a response, see WithResponseEnvelope.
+// data is the resolved data, nil if the data is null. errors is the JSON array of the errors, nil if there are none.
+// extensions is the resolved extensions object, nil if the response has no extensions.
+type ResponseEnvelope func(writer io.Writer, data, errors, extensions []byte) error
+
+// StandardResponseEnvelope writes a standard GraphQL response, e.g. {"errors":[...],"data":{...},"extensions":{...}}.
+// It's the framing used without a ResponseEnvelope.
+func StandardResponseEnvelope(writer io.Writer, data, errors, extensions []byte) (err error) {
+	err = writeSafe(err, writer, lBrace)
+	if errors != nil {
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, literalErrors)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, colon)
+		err = writeSafe(err, writer, errors)
+		err = writeSafe(err, writer, comma)
+	}
+	err = writeSafe(err, writer, quote)
+	err = writeSafe(err, writer, literalData)
+	err = writeSafe(err, writer, quote)
+	err = writeSafe(err, writer, colon)
+	err = writeDataOrNull(err, writer, data)
+	if extensions != nil {
+		err = writeSafe(err, writer, comma)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, literalExtensions)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, colon)
+		err = writeSafe(err, writer, extensions)
+	}
+	return writeSafe(err, writer, rBrace)
+}
+
+// DataOnlyResponseEnvelope writes only the data without any GraphQL framing, e.g. {"user":{...}}.
+// Errors and extensions are dropped, clients have to treat null as failure.
+func DataOnlyResponseEnvelope(writer io.Writer, data, errors, extensions []byte) error {
+	return writeDataOrNull(nil, writer, data)
+}
+
+func writeDataOrNull(err error, writer io.Writer, data []byte) error {
+	if data == nil {
+		return writeSafe(err, writer, literal.NULL)
+	}
+	return writeSafe(err, writer, data)
+}
@@ -0,0 +1,46 @@
+package resolve
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// responseStats counts the work done to resolve a response, see WithResponseStats.
+// It's shared by the clones of a Context, so all counters are updated atomically.
+type responseStats struct {
+	fields        int64
+	fetches       int64
+	fetchDuration int64
+}
+
+func (s *responseStats) countField() {
+	atomic.AddInt64(&s.fields, 1)
+}
+
+func (s *responseStats) countFields(n int) {
+	atomic.AddInt64(&s.fields, int64(n))
+}
+
+// observeFetch must be deferred when a fetch is started, start is the time the fetch was started
+func (s *responseStats) observeFetch(start time.Time) {
+	atomic.AddInt64(&s.fetches, 1)
+	atomic.AddInt64(&s.fetchDuration, int64(time.Since(start)))
+}
+
+// render returns the stats as JSON object, bytes is the size of the data and errors of the response
+func (s *responseStats) render(bytes int) []byte {
+	fetchTime := float64(atomic.LoadInt64(&s.fetchDuration)) / float64(time.Millisecond)
+
+	stats := make([]byte, 0, 96)
+	stats = append(stats, `{"bytes":`...)
+	stats = strconv.AppendInt(stats, int64(bytes), 10)
+	stats = append(stats, `,"fields":`...)
+	stats = strconv.AppendInt(stats, atomic.LoadInt64(&s.fields), 10)
+	stats = append(stats, `,"fetches":`...)
+	stats = strconv.AppendInt(stats, atomic.LoadInt64(&s.fetches), 10)
+	stats = append(stats, `,"fetchTimeMs":`...)
+	stats = strconv.AppendFloat(stats, fetchTime, 'f', 3, 64)
+	stats = append(stats, '}')
+	return stats
+}
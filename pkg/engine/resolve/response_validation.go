@@ -0,0 +1,182 @@
+package resolve
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+)
+
+// ValidateResponseAgainstPlan is a testing and diagnostic tool asserting that output, a response written by the Resolver,
+// matches the shape of the response node tree.
+// It walks both in lockstep and reports the first field which is missing, null despite being non-nullable,
+// of an unexpected JSON type or not part of the node tree.
+// Fields with a type condition, skip/include directive or defer may be absent, data may be null if the response contains errors.
+func ValidateResponseAgainstPlan(response *GraphQLResponse, output []byte) error {
+	data, dataType, _, err := jsonparser.Get(output, "data")
+	if err != nil {
+		return fmt.Errorf("response validation: data: %w", err)
+	}
+	if dataType == jsonparser.Null {
+		if _, _, _, err := jsonparser.Get(output, "errors"); err != nil {
+			return fmt.Errorf("response validation: data is null without errors")
+		}
+		return nil
+	}
+	return validateNode(response.Data, data, dataType, []string{"data"})
+}
+
+func validateNode(node Node, value []byte, valueType jsonparser.ValueType, path []string) error {
+	if valueType == jsonparser.Null {
+		if isNullableNode(node) {
+			return nil
+		}
+		return validationError(path, "non-nullable field is null")
+	}
+
+	switch n := node.(type) {
+	case *Object:
+		if valueType != jsonparser.Object {
+			return unexpectedType(path, "object", valueType)
+		}
+		return validateObject(n, value, path)
+	case *Array:
+		if valueType != jsonparser.Array {
+			return unexpectedType(path, "array", valueType)
+		}
+		var (
+			i   int
+			err error
+		)
+		_, _ = jsonparser.ArrayEach(value, func(item []byte, itemType jsonparser.ValueType, _ int, _ error) {
+			if err == nil {
+				err = validateNode(n.Item, item, itemType, append(path, strconv.Itoa(i)))
+			}
+			i++
+		})
+		return err
+	case *ScalarArray:
+		if valueType != jsonparser.Array {
+			return unexpectedType(path, "array", valueType)
+		}
+		var (
+			i   int
+			err error
+		)
+		itemType := scalarItemType(n.ItemKind)
+		_, _ = jsonparser.ArrayEach(value, func(_ []byte, dataType jsonparser.ValueType, _ int, _ error) {
+			itemPath := append(path, strconv.Itoa(i))
+			switch {
+			case err != nil:
+			case dataType == jsonparser.Null && !n.ItemNullable:
+				err = validationError(itemPath, "non-nullable field is null")
+			case dataType != jsonparser.Null:
+				err = expectType(itemPath, itemType, dataType)
+			}
+			i++
+		})
+		return err
+	case *String, *TypeName, *JSONString:
+		return expectType(path, jsonparser.String, valueType)
+	case *Integer, *Float:
+		return expectType(path, jsonparser.Number, valueType)
+	case *Boolean:
+		return expectType(path, jsonparser.Boolean, valueType)
+	case *EmptyObject:
+		if valueType != jsonparser.Object || len(value) != 2 {
+			return validationError(path, "expected empty object")
+		}
+	case *EmptyArray:
+		if valueType != jsonparser.Array || len(value) != 2 {
+			return validationError(path, "expected empty array")
+		}
+	case *Null:
+		return unexpectedType(path, "null", valueType)
+	}
+	return nil
+}
+
+func validateObject(object *Object, value []byte, path []string) error {
+	fields := make(map[string]*Field, len(object.Fields))
+	hasConditionalFields := false
+	for _, field := range object.Fields {
+		fields[string(field.Name)] = field
+		if isConditionalField(field) {
+			hasConditionalFields = true
+		}
+	}
+
+	// objects skipped because of a __typename condition are resolved to an empty object
+	if len(value) == 2 && hasConditionalFields {
+		return nil
+	}
+
+	for _, field := range object.Fields {
+		fieldValue, fieldType, _, err := jsonparser.Get(value, string(field.Name))
+		if err != nil {
+			if isConditionalField(field) || field.Defer != nil {
+				continue
+			}
+			return validationError(append(path, string(field.Name)), "field is missing")
+		}
+		if fieldType == jsonparser.Null && field.Defer != nil {
+			continue
+		}
+		if err = validateNode(field.Value, fieldValue, fieldType, append(path, string(field.Name))); err != nil {
+			return err
+		}
+	}
+
+	return jsonparser.ObjectEach(value, func(key []byte, _ []byte, _ jsonparser.ValueType, _ int) error {
+		if _, ok := fields[string(key)]; !ok {
+			return validationError(append(path, string(key)), "field is not part of the plan")
+		}
+		return nil
+	})
+}
+
+func isConditionalField(field *Field) bool {
+	return field.OnTypeName != nil || field.SkipDirectiveDefined || field.IncludeDirectiveDefined
+}
+
+func isNullableNode(node Node) bool {
+	switch n := node.(type) {
+	case *Object:
+		return n.Nullable
+	case *Array:
+		return n.Nullable
+	case *ScalarArray:
+		return n.Nullable
+	case *String:
+		return n.Nullable
+	case *Integer:
+		return n.Nullable
+	case *Float:
+		return n.Nullable
+	case *Boolean:
+		return n.Nullable
+	case *TypeName:
+		return n.Nullable
+	case *JSONString:
+		return n.Nullable
+	case *Null, *StaticValue:
+		return true
+	}
+	return false
+}
+
+func expectType(path []string, expected, actual jsonparser.ValueType) error {
+	if expected != actual {
+		return unexpectedType(path, expected.String(), actual)
+	}
+	return nil
+}
+
+func unexpectedType(path []string, expected string, actual jsonparser.ValueType) error {
+	return validationError(path, fmt.Sprintf("expected %s, got %s", expected, actual))
+}
+
+func validationError(path []string, message string) error {
+	return fmt.Errorf("response validation: %s: %s", strings.Join(path, "."), message)
+}
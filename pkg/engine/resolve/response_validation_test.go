@@ -0,0 +1,97 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateResponseAgainstPlan(t *testing.T) {
+	response := &GraphQLResponse{
+		Data: &Object{
+			Fields: []*Field{
+				{
+					Name: []byte("user"),
+					Value: &Object{
+						Nullable: true,
+						Fields: []*Field{
+							{
+								Name: []byte("id"),
+								Value: &String{
+									Path: []string{"id"},
+								},
+							},
+							{
+								Name: []byte("age"),
+								Value: &Integer{
+									Path:     []string{"age"},
+									Nullable: true,
+								},
+							},
+							{
+								Name: []byte("pets"),
+								Value: &Array{
+									Path: []string{"pets"},
+									Item: &Object{
+										Fields: []*Field{
+											{
+												Name: []byte("name"),
+												Value: &String{
+													Path: []string{"name"},
+												},
+											},
+											{
+												Name:       []byte("woof"),
+												OnTypeName: []byte("Dog"),
+												Value: &Boolean{
+													Path: []string{"woof"},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, ValidateResponseAgainstPlan(response, []byte(`{"data":{"user":{"id":"1","age":null,"pets":[{"name":"Rex","woof":true},{"name":"Tom"}]}}}`)))
+	})
+
+	t.Run("valid nullable object", func(t *testing.T) {
+		assert.NoError(t, ValidateResponseAgainstPlan(response, []byte(`{"errors":[{"message":"unable to resolve"}],"data":{"user":null}}`)))
+	})
+
+	t.Run("valid null data with errors", func(t *testing.T) {
+		assert.NoError(t, ValidateResponseAgainstPlan(response, []byte(`{"errors":[{"message":"unable to resolve"}],"data":null}`)))
+	})
+
+	t.Run("null data without errors", func(t *testing.T) {
+		assert.EqualError(t, ValidateResponseAgainstPlan(response, []byte(`{"data":null}`)),
+			"response validation: data is null without errors")
+	})
+
+	t.Run("missing non-null field", func(t *testing.T) {
+		assert.EqualError(t, ValidateResponseAgainstPlan(response, []byte(`{"data":{"user":{"age":1,"pets":[]}}}`)),
+			"response validation: data.user.id: field is missing")
+	})
+
+	t.Run("non-null field is null", func(t *testing.T) {
+		assert.EqualError(t, ValidateResponseAgainstPlan(response, []byte(`{"data":{"user":{"id":"1","age":1,"pets":[{"name":null}]}}}`)),
+			"response validation: data.user.pets.0.name: non-nullable field is null")
+	})
+
+	t.Run("unexpected type", func(t *testing.T) {
+		assert.EqualError(t, ValidateResponseAgainstPlan(response, []byte(`{"data":{"user":{"id":"1","age":"1","pets":[]}}}`)),
+			"response validation: data.user.age: expected number, got string")
+	})
+
+	t.Run("field not part of the plan", func(t *testing.T) {
+		assert.EqualError(t, ValidateResponseAgainstPlan(response, []byte(`{"data":{"user":{"id":"1","age":1,"pets":[],"name":"Jens"}}}`)),
+			"response validation: data.user.name: field is not part of the plan")
+	})
+}
@@ -0,0 +1,86 @@
+package resolve
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// ScalarTransform transforms the resolved value of a scalar before it is written, e.g. for schema directives like @uppercase.
+// value is the JSON value as it would be written, i.e. strings include their quotes and a null value is null.
+// The returned value must be valid JSON, value must not be modified as it may point into the data of a fetch.
+type ScalarTransform func(value []byte) []byte
+
+// UppercaseTransform uppercases string values, escape sequences are kept as they are. Other values are returned unchanged.
+func UppercaseTransform(value []byte) []byte {
+	if len(value) < 2 || value[0] != '"' {
+		return value
+	}
+
+	upper := make([]byte, 0, len(value))
+	for i := 0; i < len(value); {
+		if value[i] == '\\' {
+			end := i + 2
+			if end <= len(value) && value[i+1] == 'u' {
+				end = i + 6
+			}
+			if end > len(value) {
+				end = len(value)
+			}
+			upper = append(upper, value[i:end]...)
+			i = end
+			continue
+		}
+		r, size := utf8.DecodeRune(value[i:])
+		upper = utf8.AppendRune(upper, unicode.ToUpper(r))
+		i += size
+	}
+	return upper
+}
+
+// DefaultValueTransform replaces null with defaultValue, e.g. for @default(value:). defaultValue must be valid JSON.
+// Unlike the NullValue of scalars, it can be combined with other transforms, e.g. to uppercase the default value.
+// Non-nullable scalars resolving to null fail before their transforms are applied.
+func DefaultValueTransform(defaultValue []byte) ScalarTransform {
+	return func(value []byte) []byte {
+		if bytes.Equal(value, literal.NULL) {
+			return defaultValue
+		}
+		return value
+	}
+}
+
+// resolveTransformed resolves a scalar with resolve and writes its value after applying the transforms in order
+func (r *Resolver) resolveTransformed(transforms []ScalarTransform, bufPair *BufPair, resolve func(buf *BufPair) error) error {
+	buf := r.getBufPair()
+	defer r.freeBufPair(buf)
+
+	if err := resolve(buf); err != nil {
+		return err
+	}
+
+	value := buf.Data.Bytes()
+	for _, transform := range transforms {
+		value = transform(value)
+	}
+	bufPair.Data.WriteBytes(value)
+	r.MergeBufPairErrors(buf, bufPair)
+	return nil
+}
+
+// EnumValueMappingTransform maps the enum values of a backend to the values of the schema, e.g. IN_PROGRESS to InProgress.
+// mapping is keyed by the backend value, both without quotes. Unmapped values are returned unchanged.
+func EnumValueMappingTransform(mapping map[string]string) ScalarTransform {
+	quoted := make(map[string][]byte, len(mapping))
+	for backendValue, schemaValue := range mapping {
+		quoted[`"`+backendValue+`"`] = []byte(`"` + schemaValue + `"`)
+	}
+	return func(value []byte) []byte {
+		if mapped, ok := quoted[string(value)]; ok {
+			return mapped
+		}
+		return value
+	}
+}
@@ -0,0 +1,71 @@
+package resolve
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// staleFetchCache holds the data of the last successful fetch per DataSourceIdentifier and input,
+// it's served instead of the response of a failing DataSource, see WithStaleOnError.
+type staleFetchCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string][]byte
+}
+
+func newStaleFetchCache(maxEntries int) *staleFetchCache {
+	return &staleFetchCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string][]byte, maxEntries),
+	}
+}
+
+func staleFetchKey(fetch *SingleFetch, input []byte) string {
+	key := make([]byte, 0, len(fetch.DataSourceIdentifier)+1+len(input))
+	key = append(key, fetch.DataSourceIdentifier...)
+	key = append(key, 0)
+	key = append(key, input...)
+	return string(key)
+}
+
+func (c *staleFetchCache) load(fetch *SingleFetch, input []byte) (data []byte, ok bool) {
+	c.mu.Lock()
+	data, ok = c.entries[staleFetchKey(fetch, input)]
+	c.mu.Unlock()
+	return
+}
+
+func (c *staleFetchCache) store(fetch *SingleFetch, input []byte, buf *BufPair) {
+	data := make([]byte, buf.Data.Len())
+	copy(data, buf.Data.Bytes())
+	key := staleFetchKey(fetch, input)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		// evict an arbitrary entry, the cache only has to keep recently fetched inputs
+		for evict := range c.entries {
+			delete(c.entries, evict)
+			break
+		}
+	}
+	c.entries[key] = data
+}
+
+// serveStaleOnError replaces the result of a fetch failing with an error by the data of its last successful fetch.
+// It must be deferred after failFast and before logFetchFailure, so the original error is logged but doesn't fail the response.
+func (r *Resolver) serveStaleOnError(ctx *Context, fetch *SingleFetch, input []byte, buf *BufPair, err *error) {
+	if *err == nil || ctx.Context.Err() != nil {
+		return
+	}
+	data, ok := r.staleFetches.load(fetch, input)
+	if !ok {
+		return
+	}
+	buf.Reset()
+	buf.Data.WriteBytes(data)
+	if ctx.staleFetches != nil {
+		atomic.AddInt32(ctx.staleFetches, 1)
+	}
+	*err = r.postProcess(fetch, buf)
+}
@@ -0,0 +1,59 @@
+package resolve
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+type subscriptionErrorKey struct{}
+
+// subscriptionError holds the first error reported by the SubscriptionDataSource of a subscription
+type subscriptionError struct {
+	once   sync.Once
+	failed chan struct{}
+	err    error
+}
+
+func newSubscriptionError() *subscriptionError {
+	return &subscriptionError{
+		failed: make(chan struct{}),
+	}
+}
+
+func (s *subscriptionError) report(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.failed)
+	})
+}
+
+// ReportSubscriptionError reports that the subscription started with ctx failed after it has been started,
+// e.g. because the connection to the upstream was lost. ctx is the context passed to SubscriptionDataSource.Start.
+// The resolver writes err as final GraphQL error of the subscription and ends it without an error,
+// so the data sent to next before must be sent before calling ReportSubscriptionError.
+// Only the first error is reported, it's a no-op if ctx doesn't belong to a subscription.
+func ReportSubscriptionError(ctx context.Context, err error) {
+	if failure, ok := ctx.Value(subscriptionErrorKey{}).(*subscriptionError); ok && err != nil {
+		failure.report(err)
+	}
+}
+
+// writeSubscriptionError writes err as response containing only the error and flushes it
+func (r *Resolver) writeSubscriptionError(err error, writer FlushWriter) error {
+	message, _ := json.Marshal(err.Error())
+	message = message[1 : len(message)-1]
+	if r.errorRedactor != nil {
+		message = r.errorRedactor(message)
+	}
+
+	response := make([]byte, 0, len(message)+27)
+	response = append(response, `{"errors":[{"message":"`...)
+	response = append(response, message...)
+	response = append(response, `"}]}`...)
+	if _, err = writer.Write(response); err != nil {
+		return err
+	}
+	writer.Flush()
+	return nil
+}
@@ -0,0 +1,42 @@
+package resolve
+
+import "time"
+
+// subscriptionHeartbeat fires on c once no update was written for the interval, see Context.SetSubscriptionHeartbeat.
+// Without an interval c is nil, so it never fires.
+type subscriptionHeartbeat struct {
+	interval time.Duration
+	timer    *time.Timer
+	c        <-chan time.Time
+}
+
+func newSubscriptionHeartbeat(interval time.Duration) *subscriptionHeartbeat {
+	heartbeat := &subscriptionHeartbeat{
+		interval: interval,
+	}
+	if interval > 0 {
+		heartbeat.timer = time.NewTimer(interval)
+		heartbeat.c = heartbeat.timer.C
+	}
+	return heartbeat
+}
+
+// reset must be called whenever something was written, so the next heartbeat fires after a full interval
+func (h *subscriptionHeartbeat) reset() {
+	if h.timer == nil {
+		return
+	}
+	if !h.timer.Stop() {
+		select {
+		case <-h.timer.C:
+		default:
+		}
+	}
+	h.timer.Reset(h.interval)
+}
+
+func (h *subscriptionHeartbeat) stop() {
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+}
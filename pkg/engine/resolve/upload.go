@@ -0,0 +1,130 @@
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/fastbuffer"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// UploadReferenceKey is the key of the reference an UploadVariable renders for a file, e.g. {"$upload":0}
+const UploadReferenceKey = "$upload"
+
+// File is a file uploaded with a request, e.g. a file part of a GraphQL multipart request, see Context.Files
+type File struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+type filesKey struct{}
+
+// FilesFromContext returns the Context.Files of the request being resolved, e.g. to send the files referenced by the input to an upstream.
+// It is available within DataSource.Load and returns nil if the request has no files.
+func FilesFromContext(ctx context.Context) []File {
+	files, _ := ctx.Value(filesKey{}).([]File)
+	return files
+}
+
+// ContextWithFiles returns a copy of ctx carrying files, e.g. to test a DataSource, see FilesFromContext
+func ContextWithFiles(ctx context.Context, files []File) context.Context {
+	return context.WithValue(ctx, filesKey{}, files)
+}
+
+// UploadReferenceIndex returns the index of the file in Context.Files if value is a reference rendered by an UploadVariable
+func UploadReferenceIndex(value []byte) (index int, ok bool) {
+	keys := 0
+	err := jsonparser.ObjectEach(value, func(key []byte, fileIndex []byte, dataType jsonparser.ValueType, _ int) (err error) {
+		keys++
+		if string(key) != UploadReferenceKey || dataType != jsonparser.Number {
+			return fmt.Errorf("no upload reference")
+		}
+		index, err = strconv.Atoi(string(fileIndex))
+		return err
+	})
+	return index, err == nil && keys == 1
+}
+
+// UploadVariable renders the files of a variable of the Upload scalar as references to Context.Files instead of their content.
+// The value of the variable is the index of the file in Context.Files, or a list of indexes for a list of uploads,
+// e.g. {"file":0} or {"files":[0,1]}, which is rendered as {"$upload":0} or [{"$upload":0},{"$upload":1}].
+// Data sources replace the references with the files, e.g. the HTTP client sends them as a multipart request.
+type UploadVariable struct {
+	Path []string
+}
+
+func (u *UploadVariable) TemplateSegment() TemplateSegment {
+	return TemplateSegment{
+		SegmentType:        VariableSegmentType,
+		VariableKind:       UploadVariableKind,
+		VariableSourcePath: u.Path,
+	}
+}
+
+func (u *UploadVariable) GetVariableKind() VariableKind {
+	return UploadVariableKind
+}
+
+func (u *UploadVariable) Equals(another Variable) bool {
+	if another == nil {
+		return false
+	}
+	if another.GetVariableKind() != u.GetVariableKind() {
+		return false
+	}
+	anotherUploadVariable := another.(*UploadVariable)
+	if len(u.Path) != len(anotherUploadVariable.Path) {
+		return false
+	}
+	for i := range u.Path {
+		if u.Path[i] != anotherUploadVariable.Path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *InputTemplate) renderUploadVariable(ctx *Context, path []string, preparedInput *fastbuffer.FastBuffer) (err error) {
+	value, valueType, _, err := jsonparser.Get(ctx.Variables, path...)
+	switch {
+	case err != nil || valueType == jsonparser.Null:
+		preparedInput.WriteBytes(literal.NULL)
+		return nil
+	case valueType == jsonparser.Array:
+		preparedInput.WriteBytes(literal.LBRACK)
+		first := true
+		_, _ = jsonparser.ArrayEach(value, func(item []byte, itemType jsonparser.ValueType, _ int, _ error) {
+			if err != nil {
+				return
+			}
+			if !first {
+				preparedInput.WriteBytes(literal.COMMA)
+			}
+			first = false
+			err = renderUploadReference(ctx, item, itemType, preparedInput)
+		})
+		preparedInput.WriteBytes(literal.RBRACK)
+		return err
+	default:
+		return renderUploadReference(ctx, value, valueType, preparedInput)
+	}
+}
+
+func renderUploadReference(ctx *Context, value []byte, valueType jsonparser.ValueType, preparedInput *fastbuffer.FastBuffer) error {
+	if valueType == jsonparser.Null {
+		preparedInput.WriteBytes(literal.NULL)
+		return nil
+	}
+	index, err := strconv.Atoi(string(value))
+	if valueType != jsonparser.Number || err != nil || index < 0 || index >= len(ctx.Files) {
+		return fmt.Errorf("InputTemplate.Render: upload variable %s is no index of a file", value)
+	}
+	preparedInput.WriteString(`{"` + UploadReferenceKey + `":`)
+	preparedInput.WriteBytes(value)
+	preparedInput.WriteBytes(literal.RBRACE)
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"regexp"
 	"strconv"
 
 	"github.com/buger/jsonparser"
@@ -20,6 +21,8 @@ const (
 	ContextVariableKind VariableKind = iota + 1
 	ObjectVariableKind
 	HeaderVariableKind
+	IndexVariableKind
+	UploadVariableKind
 )
 
 const (
@@ -577,6 +580,28 @@ func (h *HeaderVariable) Equals(another Variable) bool {
 	return true
 }
 
+// IndexVariable renders the index of the current item of the innermost array being resolved, e.g. to compute a pagination offset
+// It renders null outside of arrays. Fetches rendering an IndexVariable aren't batched by the data loader, they're executed per item.
+type IndexVariable struct{}
+
+func (i *IndexVariable) TemplateSegment() TemplateSegment {
+	return TemplateSegment{
+		SegmentType:  VariableSegmentType,
+		VariableKind: IndexVariableKind,
+	}
+}
+
+func (i *IndexVariable) GetVariableKind() VariableKind {
+	return IndexVariableKind
+}
+
+func (i *IndexVariable) Equals(another Variable) bool {
+	if another == nil {
+		return false
+	}
+	return another.GetVariableKind() == i.GetVariableKind()
+}
+
 type Variable interface {
 	GetVariableKind() VariableKind
 	Equals(another Variable) bool
@@ -611,6 +636,35 @@ func (v *Variables) AddVariable(variable Variable) (name string, exists bool) {
 	return
 }
 
+// ValidateStaticInput returns ErrInputContainsVariablePlaceholder if input, a static part of a fetch input configured by the user,
+// contains a variable placeholder like $$0$$. The placeholder would be replaced by a variable once the input is planned,
+// so data sources reject such inputs when they are configured instead of silently corrupting them.
+func ValidateStaticInput(input string) error {
+	if placeholder := variablePlaceholderRegex.FindString(input); placeholder != "" {
+		return fmt.Errorf("%w: %s", ErrInputContainsVariablePlaceholder, placeholder)
+	}
+	return nil
+}
+
+var variablePlaceholderRegex = regexp.MustCompile(`\$\$[0-9]+\$\$`)
+
+// Len returns the number of distinct variables, the variable at index i is rendered into templates as $$i$$
+func (v *Variables) Len() int {
+	return len(*v)
+}
+
+// At returns the kind and source path of the variable at index, e.g. for tooling auditing the variables referenced by a plan.
+// The returned path is a copy and can be modified, it's nil for variables without path like the IndexVariable.
+func (v *Variables) At(index int) (kind VariableKind, path []string) {
+	variable := (*v)[index]
+	sourcePath := variable.TemplateSegment().VariableSourcePath
+	if sourcePath != nil {
+		path = make([]string, len(sourcePath))
+		copy(path, sourcePath)
+	}
+	return variable.GetVariableKind(), path
+}
+
 type VariableSchema struct {
 }
 
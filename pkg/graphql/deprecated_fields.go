@@ -0,0 +1,80 @@
+package graphql
+
+import (
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/introspection"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// DeprecatedFieldUsage is a field marked with @deprecated in the schema which is selected by an operation
+type DeprecatedFieldUsage struct {
+	TypeName  string
+	FieldName string
+	Reason    string
+}
+
+// DeprecatedFieldUsages returns the deprecated schema fields selected by the request,
+// each field is reported once in the order of its first selection.
+func (r *Request) DeprecatedFieldUsages(schema *Schema) ([]DeprecatedFieldUsage, error) {
+	if !r.IsNormalized() {
+		result, err := r.Normalize(schema)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Successful {
+			return nil, result.Errors
+		}
+	}
+
+	walker := astvisitor.NewWalker(48)
+	visitor := &deprecatedFieldsVisitor{
+		Walker:     &walker,
+		operation:  &r.document,
+		definition: &schema.document,
+		seen:       map[int]struct{}{},
+	}
+	walker.RegisterEnterFieldVisitor(visitor)
+
+	report := operationreport.Report{}
+	walker.Walk(&r.document, &schema.document, &report)
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	return visitor.usages, nil
+}
+
+type deprecatedFieldsVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	seen                  map[int]struct{}
+	usages                []DeprecatedFieldUsage
+}
+
+func (d *deprecatedFieldsVisitor) EnterField(ref int) {
+	fieldDefinition, ok := d.FieldDefinition(ref)
+	if !ok {
+		return
+	}
+	if _, ok := d.seen[fieldDefinition]; ok {
+		return
+	}
+	directive, ok := d.definition.FieldDefinitionDirectiveByName(fieldDefinition, []byte(introspection.DeprecatedDirectiveName))
+	if !ok {
+		return
+	}
+	d.seen[fieldDefinition] = struct{}{}
+	d.usages = append(d.usages, DeprecatedFieldUsage{
+		TypeName:  d.definition.NodeNameString(d.EnclosingTypeDefinition),
+		FieldName: d.definition.FieldDefinitionNameString(fieldDefinition),
+		Reason:    d.deprecationReason(directive),
+	})
+}
+
+func (d *deprecatedFieldsVisitor) deprecationReason(directive int) string {
+	if value, ok := d.definition.DirectiveArgumentValueByName(directive, []byte(introspection.DeprecationReasonArgName)); ok {
+		return d.definition.ValueContentString(value)
+	}
+	return d.definition.DirectiveDefinitionArgumentDefaultValueString(introspection.DeprecatedDirectiveName, introspection.DeprecationReasonArgName)
+}
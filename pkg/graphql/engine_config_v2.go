@@ -18,6 +18,8 @@ type EngineV2Configuration struct {
 	plannerConfig            plan.Configuration
 	websocketBeforeStartHook WebsocketBeforeStartHook
 	dataLoaderConfig         dataLoaderConfig
+	operationExtensions      bool
+	concurrentBatches        bool
 }
 
 func NewEngineV2Configuration(schema *Schema) EngineV2Configuration {
@@ -68,6 +70,18 @@ func (e *EngineV2Configuration) EnableDataLoader(enable bool) {
 	e.dataLoaderConfig.EnableDataLoader = enable
 }
 
+// EnableOperationExtensions adds the name of the executed operation as "operationName" to the extensions of query responses,
+// as well as the request id set by WithRequestID as "requestId", e.g. to correlate responses with logs.
+func (e *EngineV2Configuration) EnableOperationExtensions(enable bool) {
+	e.operationExtensions = enable
+}
+
+// EnableConcurrentBatchExecution executes the operations of a batch concurrently instead of one after another,
+// see ExecutionEngineV2.ExecuteBatch. The order of the responses is the order of the operations in both cases.
+func (e *EngineV2Configuration) EnableConcurrentBatchExecution(enable bool) {
+	e.concurrentBatches = enable
+}
+
 // SetWebsocketBeforeStartHook - sets before start hook which will be called before processing any operation sent over websockets
 func (e *EngineV2Configuration) SetWebsocketBeforeStartHook(hook WebsocketBeforeStartHook) {
 	e.websocketBeforeStartHook = hook
@@ -5,10 +5,14 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 
 	lru "github.com/hashicorp/golang-lru"
@@ -20,6 +24,7 @@ import (
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/httpclient"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
 	"github.com/wundergraph/graphql-go-tools/pkg/pool"
 	"github.com/wundergraph/graphql-go-tools/pkg/postprocess"
@@ -28,6 +33,8 @@ import (
 type EngineResultWriter struct {
 	buf           *bytes.Buffer
 	flushCallback func(data []byte)
+	// newBrotliWriter creates the compressor of the br encoding, br is unsupported if it's nil
+	newBrotliWriter func(w io.Writer) io.WriteCloser
 }
 
 func NewEngineResultWriter() EngineResultWriter {
@@ -46,6 +53,12 @@ func (e *EngineResultWriter) SetFlushCallback(flushCb func(data []byte)) {
 	e.flushCallback = flushCb
 }
 
+// SetBrotliWriter enables the br encoding, newWriter must return a brotli compressor writing to w,
+// e.g. brotli.NewWriter of github.com/andybalholm/brotli. A brotli implementation isn't part of this package to avoid the dependency.
+func (e *EngineResultWriter) SetBrotliWriter(newWriter func(w io.Writer) io.WriteCloser) {
+	e.newBrotliWriter = newWriter
+}
+
 func (e *EngineResultWriter) Write(p []byte) (n int, err error) {
 	return e.buf.Write(p)
 }
@@ -81,15 +94,19 @@ func (e *EngineResultWriter) Reset() {
 func (e *EngineResultWriter) AsHTTPResponse(status int, headers http.Header) *http.Response {
 	b := &bytes.Buffer{}
 
-	switch headers.Get(httpclient.ContentEncodingHeader) {
-	case "gzip":
+	switch encoding := headers.Get(httpclient.ContentEncodingHeader); {
+	case encoding == "gzip":
 		gzw := gzip.NewWriter(b)
 		_, _ = gzw.Write(e.Bytes())
 		_ = gzw.Close()
-	case "deflate":
+	case encoding == "deflate":
 		fw, _ := flate.NewWriter(b, 1)
 		_, _ = fw.Write(e.Bytes())
 		_ = fw.Close()
+	case encoding == "br" && e.newBrotliWriter != nil:
+		bw := e.newBrotliWriter(b)
+		_, _ = bw.Write(e.Bytes())
+		_ = bw.Close()
 	default:
 		headers.Del(httpclient.ContentEncodingHeader) // delete unsupported compression header
 		b = e.buf
@@ -104,9 +121,121 @@ func (e *EngineResultWriter) AsHTTPResponse(status int, headers http.Header) *ht
 	return res
 }
 
+// AsNegotiatedHTTPResponse is like AsHTTPResponse, but the compression is chosen by the Accept-Encoding header of the request
+// instead of the Content-Encoding of headers. br is preferred over gzip and deflate, identity is used if none of them is accepted.
+// br is only negotiated if it's enabled using SetBrotliWriter. Content-Encoding is set accordingly and Vary: Accept-Encoding is added to headers.
+func (e *EngineResultWriter) AsNegotiatedHTTPResponse(status int, headers http.Header, acceptEncoding string) *http.Response {
+	encodings := negotiatedEncodings
+	if e.newBrotliWriter != nil {
+		encodings = negotiatedEncodingsWithBrotli
+	}
+	encoding := negotiateContentEncoding(acceptEncoding, encodings)
+	if encoding == "" {
+		headers.Del(httpclient.ContentEncodingHeader)
+	} else {
+		headers.Set(httpclient.ContentEncodingHeader, encoding)
+	}
+	headers.Add("Vary", httpclient.AcceptEncodingHeader)
+	return e.AsHTTPResponse(status, headers)
+}
+
+// negotiatedEncodings are the encodings supported by AsNegotiatedHTTPResponse in the order of preference,
+// negotiatedEncodingsWithBrotli are used if br is enabled
+var (
+	negotiatedEncodings           = []string{"gzip", "deflate"}
+	negotiatedEncodingsWithBrotli = []string{"br", "gzip", "deflate"}
+)
+
+// negotiateContentEncoding returns the encoding of encodings with the highest quality value in acceptEncoding,
+// ties are broken by the order of encodings. It returns an empty string if none of encodings is accepted.
+func negotiateContentEncoding(acceptEncoding string, encodings []string) string {
+	qualities := make(map[string]float64, len(encodings))
+	wildcard := -1.0
+	for _, element := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(element, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(param[2:], 64)
+			if err != nil {
+				q = 0
+			}
+			quality = q
+		}
+		if coding == "*" {
+			wildcard = quality
+			continue
+		}
+		qualities[coding] = quality
+	}
+
+	var (
+		best        string
+		bestQuality float64
+	)
+	for _, encoding := range encodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = wildcard
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// AsStreamingHTTPResponse is like AsHTTPResponse, but the body is compressed while it's read instead of upfront,
+// so large responses aren't held in memory twice. The body is backed by a pipe, so the response has no Content-Length.
+// The EngineResultWriter must not be modified until the body is read, the body must be closed to stop the compression.
+func (e *EngineResultWriter) AsStreamingHTTPResponse(status int, headers http.Header) *http.Response {
+	var newCompressor func(w io.Writer) io.WriteCloser
+	switch encoding := headers.Get(httpclient.ContentEncodingHeader); {
+	case encoding == "gzip":
+		newCompressor = func(w io.Writer) io.WriteCloser {
+			return gzip.NewWriter(w)
+		}
+	case encoding == "deflate":
+		newCompressor = func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, 1)
+			return fw
+		}
+	case encoding == "br" && e.newBrotliWriter != nil:
+		newCompressor = e.newBrotliWriter
+	default:
+		return e.AsHTTPResponse(status, headers)
+	}
+
+	data := e.Bytes()
+	pr, pw := io.Pipe()
+	go func() {
+		compressor := newCompressor(pw)
+		_, err := compressor.Write(data)
+		if err == nil {
+			err = compressor.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	res := &http.Response{}
+	res.Body = pr
+	res.Header = headers
+	res.StatusCode = status
+	res.ContentLength = -1
+	res.Header.Del("Content-Length")
+	return res
+}
+
 type internalExecutionContext struct {
-	resolveContext *resolve.Context
-	postProcessor  *postprocess.Processor
+	resolveContext         *resolve.Context
+	postProcessor          *postprocess.Processor
+	deprecatedFieldsReport func(usages []DeprecatedFieldUsage)
+	requestID              string
+	operationRewriter      OperationRewriter
 }
 
 func newInternalExecutionContext() *internalExecutionContext {
@@ -136,6 +265,9 @@ func (e *internalExecutionContext) setVariables(variables []byte) {
 
 func (e *internalExecutionContext) reset() {
 	e.resolveContext.Free()
+	e.deprecatedFieldsReport = nil
+	e.requestID = ""
+	e.operationRewriter = nil
 }
 
 type ExecutionEngineV2 struct {
@@ -166,6 +298,42 @@ func WithAfterFetchHook(hook resolve.AfterFetchHook) ExecutionOptionsV2 {
 	}
 }
 
+func WithRootFieldMiddleware(middleware resolve.RootFieldMiddleware) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.resolveContext.SetRootFieldMiddleware(middleware)
+	}
+}
+
+// WithDeprecatedFieldUsageReport calls report with the deprecated schema fields selected by the operation before it is executed.
+// report is not called if the operation doesn't select any deprecated field.
+func WithDeprecatedFieldUsageReport(report func(usages []DeprecatedFieldUsage)) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.deprecatedFieldsReport = report
+	}
+}
+
+// WithRequestID sets the id of the request which is added to the response extensions,
+// see EngineV2Configuration.EnableOperationExtensions
+func WithRequestID(id string) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.requestID = id
+	}
+}
+
+// OperationRewriter modifies the normalized and validated operation before it is planned,
+// e.g. to inject a tenant argument into fields. definition is the schema and must not be modified.
+// The rewritten operation is normalized and validated again, so argument values added inline are extracted into variables.
+type OperationRewriter func(operation, definition *ast.Document) error
+
+// WithOperationRewriter rewrites the operation before it is planned. Plans are cached by the rewritten operation,
+// rewrites only differing in argument values share a plan as the values are extracted into variables.
+// The document of the Request is modified, a Request executed again is rewritten again.
+func WithOperationRewriter(rewriter OperationRewriter) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.operationRewriter = rewriter
+	}
+}
+
 func WithAdditionalHttpHeaders(headers http.Header, excludeByKeys ...string) ExecutionOptionsV2 {
 	return func(ctx *internalExecutionContext) {
 		if len(headers) == 0 {
@@ -225,23 +393,17 @@ func NewExecutionEngineV2(ctx context.Context, logger abstractlogger.Logger, eng
 }
 
 func (e *ExecutionEngineV2) Execute(ctx context.Context, operation *Request, writer resolve.FlushWriter, options ...ExecutionOptionsV2) error {
-	if !operation.IsNormalized() {
-		result, err := operation.Normalize(e.config.schema)
-		if err != nil {
-			return err
-		}
-
-		if !result.Successful {
-			return result.Errors
-		}
+	err := e.normalizeAndValidate(operation)
+	if err != nil {
+		return err
 	}
 
-	result, err := operation.ValidateForSchema(e.config.schema)
+	variablesResult, err := operation.ValidateVariables(e.config.schema)
 	if err != nil {
 		return err
 	}
-	if !result.Valid {
-		return result.Errors
+	if !variablesResult.Valid {
+		return variablesResult.Errors
 	}
 
 	execContext := e.getExecutionCtx()
@@ -253,6 +415,22 @@ func (e *ExecutionEngineV2) Execute(ctx context.Context, operation *Request, wri
 		options[i](execContext)
 	}
 
+	if execContext.deprecatedFieldsReport != nil {
+		usages, err := operation.DeprecatedFieldUsages(e.config.schema)
+		if err != nil {
+			return err
+		}
+		if len(usages) != 0 {
+			execContext.deprecatedFieldsReport(usages)
+		}
+	}
+
+	if execContext.operationRewriter != nil {
+		if err = e.rewriteOperation(execContext, operation); err != nil {
+			return err
+		}
+	}
+
 	var report operationreport.Report
 	cachedPlan := e.getCachedPlan(execContext, &operation.document, &e.config.schema.document, operation.OperationName, &report)
 	if report.HasErrors() {
@@ -261,7 +439,11 @@ func (e *ExecutionEngineV2) Execute(ctx context.Context, operation *Request, wri
 
 	switch p := cachedPlan.(type) {
 	case *plan.SynchronousResponsePlan:
-		err = e.resolver.ResolveGraphQLResponse(execContext.resolveContext, p.Response, nil, writer)
+		response := p.Response
+		if e.config.operationExtensions {
+			response = withOperationExtensions(response, operation.OperationName, execContext.requestID)
+		}
+		err = e.resolver.ResolveGraphQLResponse(execContext.resolveContext, response, nil, writer)
 	case *plan.SubscriptionResponsePlan:
 		err = e.resolver.ResolveGraphQLSubscription(execContext.resolveContext, p.Response, writer)
 	default:
@@ -271,6 +453,223 @@ func (e *ExecutionEngineV2) Execute(ctx context.Context, operation *Request, wri
 	return err
 }
 
+// ErrSubscriptionInBatch is the error of a subscription passed to ExecuteBatch
+var ErrSubscriptionInBatch = errors.New("subscriptions can't be executed in a batch")
+
+// ExecuteBatch executes a batch of operations sent in a single request, e.g. by Apollo clients, and writes a JSON array
+// containing the response of each operation in the order of operations. A failing operation doesn't abort the batch,
+// its response contains its errors instead. Subscriptions can't be batched and fail with an error response.
+// The operations are executed one after another unless EngineV2Configuration.EnableConcurrentBatchExecution is set,
+// options are applied to the execution of each operation.
+func (e *ExecutionEngineV2) ExecuteBatch(ctx context.Context, operations []*Request, writer resolve.FlushWriter, options ...ExecutionOptionsV2) error {
+	responses := make([]*bytes.Buffer, len(operations))
+	for i := range responses {
+		responses[i] = pool.BytesBuffer.Get()
+		defer pool.BytesBuffer.Put(responses[i])
+	}
+
+	execute := func(i int) {
+		err := e.executeBatchEntry(ctx, operations[i], responses[i], options...)
+		if err != nil {
+			responses[i].Reset()
+			_, _ = RequestErrorsFromError(err).WriteResponse(responses[i])
+		}
+	}
+
+	if e.config.concurrentBatches {
+		wg := sync.WaitGroup{}
+		wg.Add(len(operations))
+		for i := range operations {
+			go func(i int) {
+				defer wg.Done()
+				execute(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range operations {
+			execute(i)
+		}
+	}
+
+	if _, err := writer.Write(literal.LBRACK); err != nil {
+		return err
+	}
+	for i := range responses {
+		if i != 0 {
+			if _, err := writer.Write(literal.COMMA); err != nil {
+				return err
+			}
+		}
+		if _, err := writer.Write(responses[i].Bytes()); err != nil {
+			return err
+		}
+	}
+	_, err := writer.Write(literal.RBRACK)
+	return err
+}
+
+func (e *ExecutionEngineV2) executeBatchEntry(ctx context.Context, operation *Request, buf *bytes.Buffer, options ...ExecutionOptionsV2) error {
+	operationType, err := operation.OperationType()
+	if err != nil {
+		return err
+	}
+	if operationType == OperationTypeSubscription {
+		return ErrSubscriptionInBatch
+	}
+
+	return e.Execute(ctx, operation, batchEntryWriter{buf}, options...)
+}
+
+// batchEntryWriter buffers the response of an operation of a batch, it's written with the responses of the others
+type batchEntryWriter struct {
+	*bytes.Buffer
+}
+
+func (batchEntryWriter) Flush() {}
+
+// OperationPlanningError is the error of an operation passed to WarmCache which couldn't be planned
+type OperationPlanningError struct {
+	// Index is the index of the operation in the operations passed to WarmCache
+	Index     int
+	Operation string
+	Err       error
+}
+
+func (e OperationPlanningError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err)
+}
+
+func (e OperationPlanningError) Unwrap() error {
+	return e.Err
+}
+
+// OperationPlanningErrors is returned by WarmCache if at least one operation couldn't be planned
+type OperationPlanningErrors []OperationPlanningError
+
+func (e OperationPlanningErrors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].Error()
+	}
+	return fmt.Sprintf("failed to plan %d operation(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+// WarmCache normalizes, validates and plans the operations and adds their plans to the cache,
+// so the first execution of a known operation isn't slowed down by planning, e.g. on startup.
+// Each operation must be a document with a single operation. Operations failing to plan don't stop the warming of the others,
+// they are returned as OperationPlanningErrors.
+func (e *ExecutionEngineV2) WarmCache(operations []string) error {
+	execContext := e.getExecutionCtx()
+	defer e.putExecutionCtx(execContext)
+
+	var planningErrors OperationPlanningErrors
+	for i := range operations {
+		operation := Request{Query: operations[i]}
+		err := e.normalizeAndValidate(&operation)
+		if err == nil {
+			var report operationreport.Report
+			e.getCachedPlan(execContext, &operation.document, &e.config.schema.document, operation.OperationName, &report)
+			if report.HasErrors() {
+				err = report
+			}
+		}
+		if err != nil {
+			planningErrors = append(planningErrors, OperationPlanningError{
+				Index:     i,
+				Operation: operations[i],
+				Err:       err,
+			})
+		}
+	}
+
+	if len(planningErrors) != 0 {
+		return planningErrors
+	}
+	return nil
+}
+
+func (e *ExecutionEngineV2) rewriteOperation(execContext *internalExecutionContext, operation *Request) error {
+	if err := execContext.operationRewriter(&operation.document, &e.config.schema.document); err != nil {
+		return err
+	}
+
+	// the rewritten operation is normalized and validated again, the cached results belong to the operation before the rewrite
+	operation.isNormalized = false
+	operation.validForSchema = nil
+	if err := e.normalizeAndValidate(operation); err != nil {
+		return err
+	}
+	// values the rewriter added inline are extracted into variables by the normalization
+	variablesResult, err := operation.ValidateVariables(e.config.schema)
+	if err != nil {
+		return err
+	}
+	if !variablesResult.Valid {
+		return variablesResult.Errors
+	}
+	execContext.setVariables(operation.Variables)
+	return nil
+}
+
+func (e *ExecutionEngineV2) normalizeAndValidate(operation *Request) error {
+	if !operation.IsNormalized() {
+		result, err := operation.Normalize(e.config.schema)
+		if err != nil {
+			return err
+		}
+
+		if !result.Successful {
+			return result.Errors
+		}
+	}
+
+	result, err := operation.ValidateForSchema(e.config.schema)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return result.Errors
+	}
+	return nil
+}
+
+// withOperationExtensions returns a shallow copy of the response with the operation name and the request id added to its extensions,
+// the response of the cached plan itself is never modified
+func withOperationExtensions(response *resolve.GraphQLResponse, operationName, requestID string) *resolve.GraphQLResponse {
+	extensions := &resolve.Object{}
+	switch existing := response.Extensions.(type) {
+	case nil:
+	case *resolve.Object:
+		// the copy keeps the fetch and all other settings of the existing extensions, only the fields are extended
+		copied := *existing
+		copied.Fields = append(make([]*resolve.Field, 0, len(existing.Fields)+2), existing.Fields...)
+		extensions = &copied
+	default:
+		// extensions which aren't an object can't be extended
+		return response
+	}
+	addStringField := func(name, value string) {
+		if value == "" {
+			return
+		}
+		quoted, _ := json.Marshal(value)
+		extensions.Fields = append(extensions.Fields, &resolve.Field{
+			Name:  []byte(name),
+			Value: &resolve.StaticValue{Value: quoted},
+		})
+	}
+	addStringField("operationName", operationName)
+	addStringField("requestId", requestID)
+	if len(extensions.Fields) == 0 {
+		return response
+	}
+
+	withExtensions := *response
+	withExtensions.Extensions = extensions
+	return &withExtensions
+}
+
 func (e *ExecutionEngineV2) getCachedPlan(ctx *internalExecutionContext, operation, definition *ast.Document, operationName string, report *operationreport.Report) plan.Plan {
 
 	hash := pool.Hash64.Get()
@@ -4,10 +4,16 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -16,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/httpclient"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/rest_datasource"
@@ -89,6 +96,168 @@ func TestEngineResponseWriter_AsHTTPResponse(t *testing.T) {
 	})
 }
 
+// _fakeBrotliWriter marks the data it compresses instead of compressing it, so the negotiation can be tested without a brotli implementation
+type _fakeBrotliWriter struct {
+	w io.Writer
+}
+
+func (b *_fakeBrotliWriter) Write(p []byte) (n int, err error) {
+	return b.w.Write(append([]byte("br:"), p...))
+}
+
+func (b *_fakeBrotliWriter) Close() error {
+	return nil
+}
+
+func TestEngineResponseWriter_AsNegotiatedHTTPResponse(t *testing.T) {
+	testCases := []struct {
+		acceptEncoding   string
+		brotli           bool
+		expectedEncoding string
+	}{
+		{acceptEncoding: "", expectedEncoding: ""},
+		{acceptEncoding: "identity", expectedEncoding: ""},
+		{acceptEncoding: "gzip", expectedEncoding: "gzip"},
+		{acceptEncoding: "deflate", expectedEncoding: "deflate"},
+		{acceptEncoding: "gzip, deflate, br", expectedEncoding: "gzip"},
+		{acceptEncoding: "br", expectedEncoding: ""},
+		{acceptEncoding: "GZIP", expectedEncoding: "gzip"},
+		{acceptEncoding: "deflate;q=1.0, gzip;q=0.5", expectedEncoding: "deflate"},
+		{acceptEncoding: "gzip;q=0, deflate", expectedEncoding: "deflate"},
+		{acceptEncoding: "*", expectedEncoding: "gzip"},
+		{acceptEncoding: "*;q=0.1, gzip;q=0", expectedEncoding: "deflate"},
+		{acceptEncoding: "*;q=0", expectedEncoding: ""},
+		{acceptEncoding: "gzip, deflate, br", brotli: true, expectedEncoding: "br"},
+		{acceptEncoding: "br", brotli: true, expectedEncoding: "br"},
+		{acceptEncoding: "br;q=0.5, gzip", brotli: true, expectedEncoding: "gzip"},
+		{acceptEncoding: "*", brotli: true, expectedEncoding: "br"},
+		{acceptEncoding: "identity", brotli: true, expectedEncoding: ""},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(fmt.Sprintf("Accept-Encoding %q brotli %t", testCase.acceptEncoding, testCase.brotli), func(t *testing.T) {
+			rw := NewEngineResultWriter()
+			if testCase.brotli {
+				rw.SetBrotliWriter(func(w io.Writer) io.WriteCloser {
+					return &_fakeBrotliWriter{w: w}
+				})
+			}
+			_, err := rw.Write([]byte(`{"key": "value"}`))
+			require.NoError(t, err)
+
+			headers := make(http.Header)
+			headers.Set(httpclient.ContentEncodingHeader, "gzip")
+			response := rw.AsNegotiatedHTTPResponse(http.StatusOK, headers, testCase.acceptEncoding)
+			assert.Equal(t, testCase.expectedEncoding, response.Header.Get(httpclient.ContentEncodingHeader))
+			assert.Equal(t, httpclient.AcceptEncodingHeader, response.Header.Get("Vary"))
+
+			var body io.Reader = response.Body
+			switch testCase.expectedEncoding {
+			case "gzip":
+				body, err = gzip.NewReader(response.Body)
+				require.NoError(t, err)
+			case "deflate":
+				body = flate.NewReader(response.Body)
+			}
+			data, err := ioutil.ReadAll(body)
+			require.NoError(t, err)
+			if testCase.expectedEncoding == "br" {
+				assert.Equal(t, `br:{"key": "value"}`, string(data))
+				return
+			}
+			assert.Equal(t, `{"key": "value"}`, string(data))
+		})
+	}
+}
+
+func TestEngineResponseWriter_AsStreamingHTTPResponse(t *testing.T) {
+	// largeResponse returns a poorly compressible response of about size bytes
+	largeResponse := func(size int) []byte {
+		random := rand.New(rand.NewSource(1))
+		response := make([]byte, 0, size)
+		response = append(response, `{"data":"`...)
+		for len(response) < size-2 {
+			response = strconv.AppendUint(response, random.Uint64(), 36)
+		}
+		return append(response, `"}`...)
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		data := largeResponse(1 << 20)
+		rw := NewEngineResultWriter()
+		_, err := rw.Write(data)
+		require.NoError(t, err)
+
+		headers := make(http.Header)
+		headers.Set(httpclient.ContentEncodingHeader, "gzip")
+		response := rw.AsStreamingHTTPResponse(http.StatusOK, headers)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, int64(-1), response.ContentLength)
+		assert.Equal(t, "", response.Header.Get("Content-Length"))
+
+		reader, err := gzip.NewReader(response.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, data, body)
+	})
+
+	t.Run("deflate", func(t *testing.T) {
+		rw := NewEngineResultWriter()
+		_, err := rw.Write([]byte(`{"key": "value"}`))
+		require.NoError(t, err)
+
+		headers := make(http.Header)
+		headers.Set(httpclient.ContentEncodingHeader, "deflate")
+		response := rw.AsStreamingHTTPResponse(http.StatusOK, headers)
+		defer response.Body.Close()
+
+		body, err := ioutil.ReadAll(flate.NewReader(response.Body))
+		require.NoError(t, err)
+		assert.Equal(t, `{"key": "value"}`, string(body))
+	})
+
+	t.Run("no compression", func(t *testing.T) {
+		rw := NewEngineResultWriter()
+		_, err := rw.Write([]byte(`{"key": "value"}`))
+		require.NoError(t, err)
+
+		response := rw.AsStreamingHTTPResponse(http.StatusOK, make(http.Header))
+		body, err := ioutil.ReadAll(response.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"key": "value"}`, string(body))
+		assert.Equal(t, int64(16), response.ContentLength)
+	})
+
+	t.Run("memory stays bounded for a large response", func(t *testing.T) {
+		const size = 32 << 20
+		rw := NewEngineResultWriter()
+		_, err := rw.Write(largeResponse(size))
+		require.NoError(t, err)
+
+		headers := make(http.Header)
+		headers.Set(httpclient.ContentEncodingHeader, "gzip")
+
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		response := rw.AsStreamingHTTPResponse(http.StatusOK, headers)
+		reader, err := gzip.NewReader(response.Body)
+		require.NoError(t, err)
+		n, err := io.Copy(ioutil.Discard, reader)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		runtime.ReadMemStats(&after)
+		assert.Equal(t, int64(rw.Len()), n)
+		// compressing upfront allocates a buffer of the size of the compressed response, which is most of size
+		assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(size/8))
+	})
+}
+
 func TestWithAdditionalHttpHeaders(t *testing.T) {
 	reqHeader := http.Header{
 		http.CanonicalHeaderKey("X-Other-Key"):       []string{"x-other-value"},
@@ -342,6 +511,57 @@ func TestExecutionEngineV2_Execute(t *testing.T) {
 		},
 	))
 
+	heroWithFragment := func(responseBody string, expectedResponse string) ExecutionEngineV2TestCase {
+		return ExecutionEngineV2TestCase{
+			schema: starwarsSchema(t),
+			operation: func(t *testing.T) Request {
+				return Request{
+					Query: `{ hero { friends { name } ... on Droid { primaryFunction name } name ... on Human { height } } }`,
+				}
+			},
+			dataSources: []plan.DataSourceConfiguration{
+				{
+					RootNodes: []plan.TypeField{
+						{TypeName: "Query", FieldNames: []string{"hero"}},
+					},
+					ChildNodes: []plan.TypeField{
+						{TypeName: "Character", FieldNames: []string{"name", "friends"}},
+						{TypeName: "Droid", FieldNames: []string{"name", "primaryFunction", "friends"}},
+						{TypeName: "Human", FieldNames: []string{"name", "height", "friends"}},
+					},
+					Factory: &rest_datasource.Factory{
+						Client: testNetHttpClient(t, roundTripperTestCase{
+							expectedHost:     "example.com",
+							expectedPath:     "/",
+							expectedBody:     "",
+							sendResponseBody: responseBody,
+							sendStatusCode:   200,
+						}),
+					},
+					Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+						Fetch: rest_datasource.FetchConfiguration{
+							URL:    "https://example.com/",
+							Method: "GET",
+						},
+					}),
+				},
+			},
+			fields:           []plan.FieldConfiguration{},
+			expectedResponse: expectedResponse,
+		}
+	}
+
+	t.Run("execute operation preserving the selection order of fields selected in fragments and directly", func(t *testing.T) {
+		t.Run("fragment matches", runWithoutError(heroWithFragment(
+			`{"hero":{"__typename":"Droid","name":"R2-D2","primaryFunction":"Astromech","friends":[{"name":"Luke Skywalker"}]}}`,
+			`{"data":{"hero":{"friends":[{"name":"Luke Skywalker"}],"primaryFunction":"Astromech","name":"R2-D2"}}}`,
+		)))
+		t.Run("fragment doesn't match", runWithoutError(heroWithFragment(
+			`{"hero":{"__typename":"Human","name":"Luke Skywalker","height":"1.72","friends":[{"name":"R2-D2"}]}}`,
+			`{"data":{"hero":{"friends":[{"name":"R2-D2"}],"name":"Luke Skywalker","height":"1.72"}}}`,
+		)))
+	})
+
 	t.Run("execute with header injection", runWithoutError(
 		ExecutionEngineV2TestCase{
 			schema: starwarsSchema(t),
@@ -1269,6 +1489,427 @@ func TestExecutionEngineV2_Execute(t *testing.T) {
 	))
 }
 
+func TestExecutionEngineV2_DeprecatedFieldUsageReport(t *testing.T) {
+	engineConf := NewEngineV2Configuration(starwarsSchema(t))
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hero"}},
+			},
+			Factory: &rest_datasource.Factory{
+				Client: testNetHttpClient(t, roundTripperTestCase{
+					expectedHost:     "example.com",
+					expectedPath:     "/",
+					expectedBody:     "",
+					sendResponseBody: `{"hero": {"name": "Luke Skywalker"}}`,
+					sendStatusCode:   200,
+				}),
+			},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    "https://example.com/",
+					Method: "GET",
+				},
+			}),
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	var usages []DeprecatedFieldUsage
+	operation := loadStarWarsQuery(starwars.FileSimpleHeroQuery, nil)(t)
+	resultWriter := NewEngineResultWriter()
+	err = engine.Execute(context.Background(), &operation, &resultWriter, WithDeprecatedFieldUsageReport(func(deprecated []DeprecatedFieldUsage) {
+		usages = deprecated
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"data":{"hero":{"name":"Luke Skywalker"}}}`, resultWriter.String())
+	assert.Equal(t, []DeprecatedFieldUsage{
+		{TypeName: "Query", FieldName: "hero", Reason: "No longer supported"},
+	}, usages)
+}
+
+func TestExecutionEngineV2_OperationExtensions(t *testing.T) {
+	engineConf := NewEngineV2Configuration(starwarsSchema(t))
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hero"}},
+			},
+			Factory: &rest_datasource.Factory{
+				Client: testNetHttpClient(t, roundTripperTestCase{
+					expectedHost:     "example.com",
+					expectedPath:     "/",
+					expectedBody:     "",
+					sendResponseBody: `{"hero": {"name": "Luke Skywalker"}}`,
+					sendStatusCode:   200,
+				}),
+			},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    "https://example.com/",
+					Method: "GET",
+				},
+			}),
+		},
+	})
+	engineConf.EnableOperationExtensions(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, options ...ExecutionOptionsV2) string {
+		operation := Request{
+			OperationName: "HeroName",
+			Query:         "query HeroName { hero { name } }",
+		}
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &operation, &resultWriter, options...)
+		require.NoError(t, err)
+		return resultWriter.String()
+	}
+
+	t.Run("operation name and request id", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"hero":{"name":"Luke Skywalker"}},"extensions":{"operationName":"HeroName","requestId":"a1b2c3"}}`, execute(t, WithRequestID("a1b2c3")))
+	})
+
+	t.Run("operation name", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"hero":{"name":"Luke Skywalker"}},"extensions":{"operationName":"HeroName"}}`, execute(t))
+	})
+
+	t.Run("existing extensions keep their settings", func(t *testing.T) {
+		fetch := &resolve.SingleFetch{BufferId: 0}
+		existing := &resolve.Object{
+			Nullable: true,
+			Path:     []string{"extensions"},
+			Fetch:    fetch,
+			Fields: []*resolve.Field{
+				{Name: []byte("version"), Value: &resolve.StaticValue{Value: []byte(`"v1"`)}},
+			},
+		}
+		response := &resolve.GraphQLResponse{Extensions: existing}
+
+		extended := withOperationExtensions(response, "HeroName", "")
+		extensions, ok := extended.Extensions.(*resolve.Object)
+		require.True(t, ok)
+		assert.True(t, extensions.Nullable)
+		assert.Equal(t, []string{"extensions"}, extensions.Path)
+		assert.Same(t, fetch, extensions.Fetch)
+		assert.Len(t, extensions.Fields, 2)
+		assert.Len(t, existing.Fields, 1)
+	})
+}
+
+func TestExecutionEngineV2_WarmCache(t *testing.T) {
+	engineConf := NewEngineV2Configuration(starwarsSchema(t))
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hero"}},
+			},
+			Factory: &rest_datasource.Factory{
+				Client: testNetHttpClient(t, roundTripperTestCase{
+					expectedHost:     "example.com",
+					expectedPath:     "/",
+					expectedBody:     "",
+					sendResponseBody: `{"hero": {"name": "Luke Skywalker"}}`,
+					sendStatusCode:   200,
+				}),
+			},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    "https://example.com/",
+					Method: "GET",
+				},
+			}),
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	t.Run("warmed operation is served from cache", func(t *testing.T) {
+		t.Cleanup(engine.executionPlanCache.Purge)
+
+		err := engine.WarmCache([]string{"query HeroName { hero { name } }"})
+		require.NoError(t, err)
+		require.Equal(t, 1, engine.executionPlanCache.Len())
+		_, warmedPlan, _ := engine.executionPlanCache.GetOldest()
+
+		operation := Request{
+			OperationName: "HeroName",
+			Query:         "query HeroName { hero { name } }",
+		}
+		resultWriter := NewEngineResultWriter()
+		err = engine.Execute(context.Background(), &operation, &resultWriter)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"hero":{"name":"Luke Skywalker"}}}`, resultWriter.String())
+
+		_, executedPlan, _ := engine.executionPlanCache.GetOldest()
+		assert.Equal(t, 1, engine.executionPlanCache.Len())
+		assert.Same(t, warmedPlan, executedPlan)
+	})
+
+	t.Run("operations failing to plan are reported", func(t *testing.T) {
+		t.Cleanup(engine.executionPlanCache.Purge)
+
+		err := engine.WarmCache([]string{
+			"query HeroName { hero { name } }",
+			"query Unknown { unknownField }",
+			"query Invalid {",
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, engine.executionPlanCache.Len())
+
+		var planningErrors OperationPlanningErrors
+		require.True(t, errors.As(err, &planningErrors))
+		require.Len(t, planningErrors, 2)
+		assert.Equal(t, 1, planningErrors[0].Index)
+		assert.Equal(t, "query Unknown { unknownField }", planningErrors[0].Operation)
+		assert.Equal(t, 2, planningErrors[1].Index)
+		assert.Equal(t, "query Invalid {", planningErrors[1].Operation)
+	})
+}
+
+func TestExecutionEngineV2_VariableValidation(t *testing.T) {
+	engineConf := NewEngineV2Configuration(starwarsSchema(t))
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"droid"}},
+			},
+			Factory: &rest_datasource.Factory{
+				Client: testNetHttpClient(t, roundTripperTestCase{
+					expectedHost:     "example.com",
+					expectedPath:     "/",
+					expectedBody:     "",
+					sendResponseBody: `{"droid": {"name": "R2D2"}}`,
+					sendStatusCode:   200,
+				}),
+			},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    "https://example.com/",
+					Method: "GET",
+				},
+			}),
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	execute := func(variables string) (string, error) {
+		operation := Request{
+			OperationName: "Droid",
+			Variables:     []byte(variables),
+			Query:         `query Droid($id: ID!) { droid(id: $id) { name } }`,
+		}
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &operation, &resultWriter)
+		return resultWriter.String(), err
+	}
+
+	t.Run("valid variables", func(t *testing.T) {
+		out, err := execute(`{"id":"2001"}`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"droid":{"name":"R2D2"}}}`, out)
+	})
+
+	t.Run("missing required variable", func(t *testing.T) {
+		out, err := execute(`{}`)
+		assert.Equal(t, RequestErrors{{Message: `Variable "$id" of required type "ID!" was not provided.`}}, err)
+		assert.Empty(t, out)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		out, err := execute(`{"id":true}`)
+		assert.Equal(t, RequestErrors{{Message: `Variable "$id" got invalid value true; expected type "ID!"`}}, err)
+		assert.Empty(t, out)
+	})
+}
+
+func TestExecutionEngineV2_ExecuteBatch(t *testing.T) {
+	newEngine := func(t *testing.T, concurrent bool) *ExecutionEngineV2 {
+		engineConf := NewEngineV2Configuration(starwarsSchema(t))
+		engineConf.SetDataSources([]plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{TypeName: "Query", FieldNames: []string{"hero"}},
+				},
+				Factory: &rest_datasource.Factory{
+					Client: testNetHttpClient(t, roundTripperTestCase{
+						expectedHost:     "example.com",
+						expectedPath:     "/",
+						expectedBody:     "",
+						sendResponseBody: `{"hero": {"name": "Luke Skywalker"}}`,
+						sendStatusCode:   200,
+					}),
+				},
+				Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+					Fetch: rest_datasource.FetchConfiguration{
+						URL:    "https://example.com/",
+						Method: "GET",
+					},
+				}),
+			},
+		})
+		engineConf.EnableConcurrentBatchExecution(concurrent)
+
+		engine, err := NewExecutionEngineV2(context.Background(), abstractlogger.Noop{}, engineConf)
+		require.NoError(t, err)
+		return engine
+	}
+
+	executeBatch := func(t *testing.T, engine *ExecutionEngineV2) string {
+		operations := []*Request{
+			{Query: "query HeroName { hero { name } }"},
+			{Query: "query Unknown { unknownField }"},
+			{Query: "subscription Jedis { remainingJedis }"},
+			{Query: "{ hero { name } }"},
+		}
+		resultWriter := NewEngineResultWriter()
+		err := engine.ExecuteBatch(context.Background(), operations, &resultWriter)
+		require.NoError(t, err)
+		return resultWriter.String()
+	}
+
+	expected := `[` +
+		`{"data":{"hero":{"name":"Luke Skywalker"}}},` +
+		`{"errors":[{"message":"field: unknownField not defined on type: Query","path":["query","unknownField"]}]},` +
+		`{"errors":[{"message":"subscriptions can't be executed in a batch"}]},` +
+		`{"data":{"hero":{"name":"Luke Skywalker"}}}` +
+		`]`
+
+	t.Run("sequential", func(t *testing.T) {
+		assert.Equal(t, expected, executeBatch(t, newEngine(t, false)))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		assert.Equal(t, expected, executeBatch(t, newEngine(t, true)))
+	})
+}
+
+func TestExecutionEngineV2_OperationRewriter(t *testing.T) {
+	engineConf := NewEngineV2Configuration(heroWithArgumentSchema(t))
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hero"}},
+			},
+			Factory: &rest_datasource.Factory{
+				Client: &http.Client{
+					Transport: testRoundTripper(func(req *http.Request) *http.Response {
+						body := fmt.Sprintf(`{"race": "Human from %s"}`, req.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       ioutil.NopCloser(strings.NewReader(body)),
+						}
+					}),
+				},
+			},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    "https://example.com/name/{{ .arguments.name }}",
+					Method: "GET",
+				},
+			}),
+		},
+	})
+	engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+		{
+			TypeName:  "Query",
+			FieldName: "hero",
+			Path:      []string{"race"},
+			Arguments: []plan.ArgumentConfiguration{
+				{
+					Name:         "name",
+					RenderConfig: plan.RenderArgumentDefault,
+				},
+			},
+		},
+	})
+
+	engine, err := NewExecutionEngineV2(context.Background(), abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	injectName := func(name string) OperationRewriter {
+		return func(operation, definition *ast.Document) error {
+			for ref := range operation.Fields {
+				if operation.FieldNameString(ref) != "hero" || operation.Fields[ref].HasArguments {
+					continue
+				}
+				value := ast.Value{
+					Kind: ast.ValueKindString,
+					Ref:  operation.ImportStringValue([]byte(name), false),
+				}
+				operation.AddArgumentToField(ref, operation.ImportArgument("name", value))
+			}
+			return nil
+		}
+	}
+
+	execute := func(t *testing.T, options ...ExecutionOptionsV2) string {
+		operation := Request{Query: "{ hero }"}
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &operation, &resultWriter, options...)
+		require.NoError(t, err)
+		return resultWriter.String()
+	}
+
+	assert.Equal(t, `{"data":{"hero":"Human from /name/luke"}}`, execute(t, WithOperationRewriter(injectName("luke"))))
+	// the injected arguments are extracted into variables, so the rewritten operations share a plan
+	assert.Equal(t, `{"data":{"hero":"Human from /name/leia"}}`, execute(t, WithOperationRewriter(injectName("leia"))))
+	assert.Equal(t, 1, engine.executionPlanCache.Len())
+
+	assert.Equal(t, `{"data":{"hero":"Human from /name/"}}`, execute(t))
+	assert.Equal(t, 2, engine.executionPlanCache.Len())
+
+	injectArgument := func(name string, value ast.Value) OperationRewriter {
+		return func(operation, definition *ast.Document) error {
+			for ref := range operation.Fields {
+				if operation.FieldNameString(ref) == "hero" {
+					operation.AddArgumentToField(ref, operation.ImportArgument(name, value))
+				}
+			}
+			return nil
+		}
+	}
+
+	executeWithError := func(t *testing.T, rewriter OperationRewriter) error {
+		operation := Request{Query: "{ hero }"}
+		resultWriter := NewEngineResultWriter()
+		return engine.Execute(context.Background(), &operation, &resultWriter, WithOperationRewriter(rewriter))
+	}
+
+	t.Run("rewritten operation is validated", func(t *testing.T) {
+		err := executeWithError(t, injectArgument("bogus", ast.Value{Kind: ast.ValueKindBoolean, Ref: 1}))
+		assert.EqualError(t, err, "argument: bogus not defined on node: hero, locations: [], path: [query,hero]")
+	})
+
+	t.Run("extracted variables of the rewritten operation are validated", func(t *testing.T) {
+		rewriter := func(operation, definition *ast.Document) error {
+			return injectArgument("name", ast.Value{
+				Kind: ast.ValueKindInteger,
+				Ref:  operation.ImportIntValue([]byte("1"), false),
+			})(operation, definition)
+		}
+		err := executeWithError(t, rewriter)
+		// the value is rejected by the variable validation before the plan renders it
+		assert.EqualError(t, err, `Variable "$a" got invalid value 1; expected type "String", locations: [], path: []`)
+	})
+}
+
 func TestExecutionEngineV2_FederationAndSubscription_IntegrationTest(t *testing.T) {
 
 	runIntegration := func(t *testing.T, enableDataLoader bool, secondRun bool) {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"strings"
+	"sync"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/astnormalization"
@@ -36,6 +37,8 @@ type Schema struct {
 	document     ast.Document
 	isNormalized bool
 	hash         uint64
+	// variableValidators caches the compiled validators of variable types by the printed type, e.g. [ID!]!, see Request.ValidateVariables
+	variableValidators sync.Map
 }
 
 // Hash returns the hash of the schema.
@@ -1,7 +1,14 @@
 package graphql
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/astvalidation"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphqljsonschema"
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
 )
 
@@ -40,6 +47,85 @@ func (r *Request) ValidateForSchema(schema *Schema) (result ValidationResult, er
 	return result, err
 }
 
+// ValidateVariables validates the Variables of the request against the variable definitions of the executed operation:
+// variables of a non-null type without a default value must be provided and all values must match their type.
+// Variables which aren't defined by the operation are ignored.
+func (r *Request) ValidateVariables(schema *Schema) (result ValidationResult, err error) {
+	if schema == nil {
+		return ValidationResult{Valid: false, Errors: nil}, ErrNilSchema
+	}
+
+	report := r.parseQueryOnce()
+	if report.HasErrors() {
+		return operationValidationResultFromReport(report)
+	}
+
+	operationRef := r.operationDefinitionRef()
+	if operationRef == -1 {
+		return ValidationResult{Valid: true}, nil
+	}
+
+	var errs RequestErrors
+	for _, ref := range r.document.OperationDefinitions[operationRef].VariableDefinitions.Refs {
+		name := r.document.VariableDefinitionNameString(ref)
+		typeRef := r.document.VariableDefinitions[ref].Type
+		typeName, err := r.document.PrintTypeBytes(typeRef, nil)
+		if err != nil {
+			return ValidationResult{Valid: false}, err
+		}
+
+		value, valueType, offset, getErr := jsonparser.Get(r.Variables, name)
+		if getErr != nil {
+			if r.document.TypeIsNonNull(typeRef) && !r.document.VariableDefinitionHasDefaultValue(ref) {
+				errs = append(errs, RequestError{Message: fmt.Sprintf(`Variable "$%s" of required type "%s" was not provided.`, name, typeName)})
+			}
+			continue
+		}
+		if valueType == jsonparser.String {
+			value = r.Variables[offset-len(value)-2 : offset]
+		}
+
+		validator, err := schema.variableValidator(&r.document, typeRef, typeName)
+		if err != nil {
+			return ValidationResult{Valid: false}, err
+		}
+		if err = validator.Validate(context.Background(), value); err != nil {
+			errs = append(errs, RequestError{Message: fmt.Sprintf(`Variable "$%s" got invalid value %s; expected type "%s"`, name, value, typeName)})
+		}
+	}
+
+	return ValidationResult{Valid: len(errs) == 0, Errors: errs}, nil
+}
+
+// variableValidator returns the validator of the variable type at typeRef of operation, typeName is the printed type.
+// The compiled validators are cached with the schema, as compiling a JSON schema on every request is expensive.
+// The validator of a type only depends on the schema, so it's shared by all operations.
+func (s *Schema) variableValidator(operation *ast.Document, typeRef int, typeName []byte) (*graphqljsonschema.Validator, error) {
+	if cached, ok := s.variableValidators.Load(string(typeName)); ok {
+		return cached.(*graphqljsonschema.Validator), nil
+	}
+	validator, err := graphqljsonschema.NewValidatorFromSchema(graphqljsonschema.FromTypeRef(operation, &s.document, typeRef))
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := s.variableValidators.LoadOrStore(string(typeName), validator)
+	return cached.(*graphqljsonschema.Validator), nil
+}
+
+// operationDefinitionRef returns the operation definition selected by the OperationName or -1 if there's none
+func (r *Request) operationDefinitionRef() int {
+	for i := range r.document.RootNodes {
+		if r.document.RootNodes[i].Kind != ast.NodeKindOperationDefinition {
+			continue
+		}
+		ref := r.document.RootNodes[i].Ref
+		if r.OperationName == "" || r.document.OperationDefinitionNameString(ref) == r.OperationName {
+			return ref
+		}
+	}
+	return -1
+}
+
 // ValidateRestrictedFields validates a request by checking if `restrictedFields` contains blocked fields.
 //
 // Deprecated: This function can only handle blocked fields. Use `ValidateFieldRestrictions` if you
@@ -291,6 +291,77 @@ func TestRequest_ValidateFieldRestrictions(t *testing.T) {
 
 }
 
+func TestRequest_ValidateVariables(t *testing.T) {
+	schema, err := NewSchemaFromString("schema { query: Query } type Query { users(name: String!, limit: Int, active: Boolean = true): String }")
+	require.NoError(t, err)
+
+	validate := func(t *testing.T, variables string) ValidationResult {
+		request := Request{
+			OperationName: "Users",
+			Variables:     []byte(variables),
+			Query:         `query Users($name: String!, $limit: Int, $active: Boolean! = true) { users(name: $name, limit: $limit, active: $active) }`,
+		}
+		result, err := request.ValidateVariables(schema)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("should return error when schema is nil", func(t *testing.T) {
+		request := Request{Query: `query Users($name: String!) { users(name: $name) }`}
+		result, err := request.ValidateVariables(nil)
+		assert.Equal(t, ErrNilSchema, err)
+		assert.False(t, result.Valid)
+	})
+
+	t.Run("valid variables", func(t *testing.T) {
+		result := validate(t, `{"name":"Jens","limit":10}`)
+		assert.True(t, result.Valid)
+		assert.Nil(t, result.Errors)
+	})
+
+	t.Run("missing required variable", func(t *testing.T) {
+		result := validate(t, `{"limit":10}`)
+		assert.False(t, result.Valid)
+		assert.Equal(t, RequestErrors{{Message: `Variable "$name" of required type "String!" was not provided.`}}, result.Errors)
+	})
+
+	t.Run("missing variables", func(t *testing.T) {
+		result := validate(t, ``)
+		assert.False(t, result.Valid)
+		assert.Equal(t, RequestErrors{{Message: `Variable "$name" of required type "String!" was not provided.`}}, result.Errors)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		result := validate(t, `{"name":"Jens","limit":"ten"}`)
+		assert.False(t, result.Valid)
+		assert.Equal(t, RequestErrors{{Message: `Variable "$limit" got invalid value "ten"; expected type "Int"`}}, result.Errors)
+	})
+
+	t.Run("null for required variable", func(t *testing.T) {
+		result := validate(t, `{"name":null}`)
+		assert.False(t, result.Valid)
+		assert.Equal(t, RequestErrors{{Message: `Variable "$name" got invalid value null; expected type "String!"`}}, result.Errors)
+	})
+
+	t.Run("validators are compiled once per type", func(t *testing.T) {
+		first, ok := schema.variableValidators.Load("Int")
+		require.True(t, ok)
+
+		result := validate(t, `{"name":"Jens","limit":"ten"}`)
+		assert.False(t, result.Valid)
+		second, ok := schema.variableValidators.Load("Int")
+		require.True(t, ok)
+		assert.Same(t, first, second)
+
+		var types []string
+		schema.variableValidators.Range(func(key, value interface{}) bool {
+			types = append(types, key.(string))
+			return true
+		})
+		assert.ElementsMatch(t, []string{"String!", "Int"}, types)
+	})
+}
+
 func Test_operationValidationResultFromReport(t *testing.T) {
 	t.Run("should return result for valid when report does not have errors", func(t *testing.T) {
 		report := operationreport.Report{}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/buger/jsonparser"
 	"github.com/qri-io/jsonschema"
@@ -175,7 +176,10 @@ func (r *fromTypeRefResolver) fromTypeRef(operation, definition *ast.Document, t
 	return NewObject(nonNull)
 }
 
+// Validator is safe for concurrent use, e.g. when shared by the variable renderers of a cached plan.
 type Validator struct {
+	// mu serializes validations as the schema registers itself and resolves refs lazily while validating
+	mu     sync.Mutex
 	schema jsonschema.Schema
 }
 
@@ -240,7 +244,9 @@ func TopLevelType(schema string) (jsonparser.ValueType, error) {
 }
 
 func (v *Validator) Validate(ctx context.Context, inputJSON []byte) error {
+	v.mu.Lock()
 	errs, err := v.schema.ValidateBytes(ctx, inputJSON)
+	v.mu.Unlock()
 	if err != nil {
 		// There was an issue performing the validation itself. Return a
 		// generic error so the input isn't exposed.
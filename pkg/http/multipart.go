@@ -0,0 +1,75 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+)
+
+const (
+	multipartBoundary string = "-"
+
+	httpContentTypeMultipartMixed string = `multipart/mixed; boundary="` + multipartBoundary + `"`
+)
+
+var (
+	multipartPartHeader = []byte("\r\n--" + multipartBoundary + "\r\nContent-Type: application/json; charset=utf-8\r\n\r\n")
+	multipartClose      = []byte("\r\n--" + multipartBoundary + "--\r\n")
+)
+
+// MultipartWriter is a resolve.FlushWriter writing each flushed payload as part of a multipart/mixed response,
+// as expected by clients supporting incremental delivery (@defer, @stream) over HTTP:
+//
+//	---
+//	Content-Type: application/json; charset=utf-8
+//
+//	{"data":{...},"hasNext":true}
+//	-----
+//
+// Close must be called once all payloads are written to emit the terminating boundary.
+type MultipartWriter struct {
+	writer  http.ResponseWriter
+	flusher http.Flusher
+	buf     bytes.Buffer
+}
+
+// NewMultipartWriter sets the multipart/mixed Content-Type header on w
+// Headers must not be written to w before, as the first part writes the status code 200
+func NewMultipartWriter(w http.ResponseWriter) *MultipartWriter {
+	w.Header().Set(httpHeaderContentType, httpContentTypeMultipartMixed)
+	flusher, _ := w.(http.Flusher)
+	return &MultipartWriter{
+		writer:  w,
+		flusher: flusher,
+	}
+}
+
+// Write buffers p until Flush is called
+func (m *MultipartWriter) Write(p []byte) (n int, err error) {
+	return m.buf.Write(p)
+}
+
+// Flush writes the buffered payload as part and flushes the underlying http.ResponseWriter
+func (m *MultipartWriter) Flush() {
+	if m.buf.Len() == 0 {
+		return
+	}
+	defer m.buf.Reset()
+
+	_, _ = m.writer.Write(multipartPartHeader)
+	_, _ = m.writer.Write(m.buf.Bytes())
+	m.flush()
+}
+
+// Close flushes pending data and writes the terminating boundary
+func (m *MultipartWriter) Close() error {
+	m.Flush()
+	_, err := m.writer.Write(multipartClose)
+	m.flush()
+	return err
+}
+
+func (m *MultipartWriter) flush() {
+	if m.flusher != nil {
+		m.flusher.Flush()
+	}
+}
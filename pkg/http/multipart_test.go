@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultipartWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := NewMultipartWriter(recorder)
+
+	_, err := writer.Write([]byte(`{"data":{"hero":{"name":"Luke"}},"hasNext":true}`))
+	assert.NoError(t, err)
+	writer.Flush()
+	assert.True(t, recorder.Flushed)
+
+	_, err = writer.Write([]byte(`[{"op":"add","path":"/data/hero/friends","value":[]}]`))
+	assert.NoError(t, err)
+	writer.Flush()
+	writer.Flush()
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, `multipart/mixed; boundary="-"`, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"+
+		`{"data":{"hero":{"name":"Luke"}},"hasNext":true}`+
+		"\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"+
+		`[{"op":"add","path":"/data/hero/friends","value":[]}]`+
+		"\r\n-----\r\n", recorder.Body.String())
+}
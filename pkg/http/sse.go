@@ -0,0 +1,93 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+)
+
+const (
+	httpHeaderCacheControl string = "Cache-Control"
+	httpHeaderConnection   string = "Connection"
+
+	httpContentTypeEventStream string = "text/event-stream"
+)
+
+var (
+	sseEventNext     = []byte("event: next\n")
+	sseEventComplete = []byte("event: complete\ndata:\n\n")
+	sseHeartbeat     = []byte(":\n\n")
+	sseData          = []byte("data: ")
+	sseLineBreak     = []byte("\n")
+)
+
+// SSEWriter is a resolve.FlushWriter writing each flushed subscription update
+// as Server-Sent Event following the GraphQL over SSE protocol (distinct connections mode):
+//
+//	event: next
+//	data: {"data":{...}}
+//
+// Complete must be called once the subscription is done to emit the complete event.
+type SSEWriter struct {
+	writer  http.ResponseWriter
+	flusher http.Flusher
+	buf     bytes.Buffer
+}
+
+// NewSSEWriter sets the event stream headers on w
+// Headers must not be written to w before, as the first event writes the status code 200
+func NewSSEWriter(w http.ResponseWriter) *SSEWriter {
+	w.Header().Set(httpHeaderContentType, httpContentTypeEventStream)
+	w.Header().Set(httpHeaderCacheControl, "no-cache")
+	w.Header().Set(httpHeaderConnection, "keep-alive")
+	flusher, _ := w.(http.Flusher)
+	return &SSEWriter{
+		writer:  w,
+		flusher: flusher,
+	}
+}
+
+// Write buffers p until Flush is called
+func (s *SSEWriter) Write(p []byte) (n int, err error) {
+	return s.buf.Write(p)
+}
+
+// Flush writes the buffered update as next event and flushes the underlying http.ResponseWriter
+// Line breaks inside the update are written as multiple data lines
+func (s *SSEWriter) Flush() {
+	if s.buf.Len() == 0 {
+		return
+	}
+	defer s.buf.Reset()
+
+	_, _ = s.writer.Write(sseEventNext)
+	for _, line := range bytes.Split(bytes.TrimRight(s.buf.Bytes(), "\r\n"), sseLineBreak) {
+		_, _ = s.writer.Write(sseData)
+		_, _ = s.writer.Write(bytes.TrimRight(line, "\r"))
+		_, _ = s.writer.Write(sseLineBreak)
+	}
+	_, _ = s.writer.Write(sseLineBreak)
+	s.flush()
+}
+
+// WriteHeartbeat writes an empty comment, which keeps the connection open without emitting an event,
+// it implements resolve.HeartbeatWriter
+func (s *SSEWriter) WriteHeartbeat() error {
+	if _, err := s.writer.Write(sseHeartbeat); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+// Complete writes the complete event, signaling the client that no more events follow
+func (s *SSEWriter) Complete() {
+	s.Flush()
+	_, _ = s.writer.Write(sseEventComplete)
+	s.flush()
+}
+
+func (s *SSEWriter) flush() {
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
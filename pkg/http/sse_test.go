@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSEWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := NewSSEWriter(recorder)
+
+	_, err := writer.Write([]byte(`{"data":{"counter":1}}`))
+	assert.NoError(t, err)
+	writer.Flush()
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, "event: next\ndata: {\"data\":{\"counter\":1}}\n\n", recorder.Body.String())
+
+	_, err = writer.Write([]byte("{\"data\":\n{\"counter\":2}}"))
+	assert.NoError(t, err)
+	writer.Flush()
+	writer.Flush()
+	writer.Complete()
+
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, "event: next\ndata: {\"data\":{\"counter\":1}}\n\n"+
+		"event: next\ndata: {\"data\":\ndata: {\"counter\":2}}\n\n"+
+		"event: complete\ndata:\n\n", recorder.Body.String())
+}
+
+func TestSSEWriter_WriteHeartbeat(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := NewSSEWriter(recorder)
+
+	assert.NoError(t, writer.WriteHeartbeat())
+	assert.True(t, recorder.Flushed)
+	_, err := writer.Write([]byte(`{"data":{"counter":1}}`))
+	assert.NoError(t, err)
+	writer.Flush()
+
+	assert.Equal(t, ":\n\nevent: next\ndata: {\"data\":{\"counter\":1}}\n\n", recorder.Body.String())
+}
@@ -73,7 +73,7 @@ func (d *ProcessDataSource) resolveInputTemplate(variables resolve.Variables, in
 		return
 	}
 
-	if !strings.Contains(input, "$$") {
+	if !strings.Contains(input, variablePlaceholderDelimiter) {
 		template.Segments = append(template.Segments, resolve.TemplateSegment{
 			SegmentType: resolve.StaticSegmentType,
 			Data:        []byte(input),
@@ -81,22 +81,57 @@ func (d *ProcessDataSource) resolveInputTemplate(variables resolve.Variables, in
 		return
 	}
 
-	segments := strings.Split(input, "$$")
-
-	isVariable := false
-	for _, seg := range segments {
-		switch {
-		case isVariable:
-			i, _ := strconv.Atoi(seg)
-			variableTemplateSegment := (variables)[i].TemplateSegment()
-			template.Segments = append(template.Segments, variableTemplateSegment)
-			isVariable = false
-		default:
-			template.Segments = append(template.Segments, resolve.TemplateSegment{
+	// only $$index$$ of an existing variable is a placeholder, other occurrences of $$ are part of the static input,
+	// e.g. in a string argument of a query
+	static := 0
+	for searchFrom := 0; ; {
+		start := strings.Index(input[searchFrom:], variablePlaceholderDelimiter)
+		if start == -1 {
+			break
+		}
+		start += searchFrom
+		indexStart := start + len(variablePlaceholderDelimiter)
+		end := strings.Index(input[indexStart:], variablePlaceholderDelimiter)
+		if end == -1 {
+			break
+		}
+		end += indexStart
+		index, ok := variableIndex(input[indexStart:end], len(variables))
+		if !ok {
+			searchFrom = indexStart
+			continue
+		}
+		template.Segments = append(template.Segments,
+			resolve.TemplateSegment{
 				SegmentType: resolve.StaticSegmentType,
-				Data:        []byte(seg),
-			})
-			isVariable = true
+				Data:        []byte(input[static:start]),
+			},
+			variables[index].TemplateSegment(),
+		)
+		static = end + len(variablePlaceholderDelimiter)
+		searchFrom = static
+	}
+	template.Segments = append(template.Segments, resolve.TemplateSegment{
+		SegmentType: resolve.StaticSegmentType,
+		Data:        []byte(input[static:]),
+	})
+}
+
+const variablePlaceholderDelimiter = "$$"
+
+// variableIndex parses the index of a variable placeholder, ok is false if it's not the index of one of the variables
+func variableIndex(index string, variables int) (int, bool) {
+	if index == "" {
+		return 0, false
+	}
+	for i := range index {
+		if index[i] < '0' || index[i] > '9' {
+			return 0, false
 		}
 	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i >= variables {
+		return 0, false
+	}
+	return i, true
 }
@@ -310,3 +310,51 @@ func TestDataSourceInput_Subscription_Process(t *testing.T) {
 
 	assert.Equal(t, expected, actual)
 }
+
+func TestDataSourceInput_Process_DollarSignsInInput(t *testing.T) {
+	pre := &plan.SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fetch: &resolve.SingleFetch{
+					Input: `{"body":{"query":"{products(note: \"costs $$5, $$abc$$ or $$9$$\", id: $$0$$) {name}}"}}`,
+					Variables: resolve.NewVariables(
+						&resolve.ContextVariable{
+							Path: []string{"id"},
+						},
+					),
+				},
+			},
+		},
+	}
+
+	expected := &plan.SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fetch: &resolve.SingleFetch{
+					InputTemplate: resolve.InputTemplate{
+						Segments: []resolve.TemplateSegment{
+							{
+								Data:        []byte(`{"body":{"query":"{products(note: \"costs $$5, $$abc$$ or $$9$$\", id: `),
+								SegmentType: resolve.StaticSegmentType,
+							},
+							{
+								SegmentType:        resolve.VariableSegmentType,
+								VariableKind:       resolve.ContextVariableKind,
+								VariableSourcePath: []string{"id"},
+							},
+							{
+								Data:        []byte(`) {name}}"}}`),
+								SegmentType: resolve.StaticSegmentType,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	processor := &ProcessDataSource{}
+	actual := processor.Process(pre)
+
+	assert.Equal(t, expected, actual)
+}
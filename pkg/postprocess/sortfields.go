@@ -0,0 +1,46 @@
+package postprocess
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+)
+
+// ProcessSortFields sorts the fields of all objects of a plan by name, so a Resolver created with resolve.WithSortedKeys
+// resolves them in order instead of sorting them on each request. Fields with the same name keep their order.
+// It isn't part of the DefaultProcessor as it changes the order of the fields in the response for all Resolvers.
+type ProcessSortFields struct{}
+
+func (s *ProcessSortFields) Process(pre plan.Plan) plan.Plan {
+	switch t := pre.(type) {
+	case *plan.SynchronousResponsePlan:
+		s.traverseNode(t.Response.Data)
+		s.traverseNode(t.Response.Extensions)
+	case *plan.StreamingResponsePlan:
+		s.traverseNode(t.Response.InitialResponse.Data)
+		s.traverseNode(t.Response.InitialResponse.Extensions)
+		for i := range t.Response.Patches {
+			s.traverseNode(t.Response.Patches[i].Value)
+		}
+	case *plan.SubscriptionResponsePlan:
+		s.traverseNode(t.Response.Response.Data)
+		s.traverseNode(t.Response.Response.Extensions)
+	}
+	return pre
+}
+
+func (s *ProcessSortFields) traverseNode(node resolve.Node) {
+	switch n := node.(type) {
+	case *resolve.Object:
+		sort.SliceStable(n.Fields, func(i, j int) bool {
+			return bytes.Compare(n.Fields[i].Name, n.Fields[j].Name) < 0
+		})
+		for i := range n.Fields {
+			s.traverseNode(n.Fields[i].Value)
+		}
+	case *resolve.Array:
+		s.traverseNode(n.Item)
+	}
+}
@@ -0,0 +1,137 @@
+package postprocess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+)
+
+func TestProcessSortFields_Process(t *testing.T) {
+	pre := &plan.SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fields: []*resolve.Field{
+					{
+						Name: []byte("user"),
+						Value: &resolve.Object{
+							Path: []string{"user"},
+							Fields: []*resolve.Field{
+								{
+									Name:  []byte("name"),
+									Value: &resolve.String{Path: []string{"name"}},
+								},
+								{
+									Name: []byte("pets"),
+									Value: &resolve.Array{
+										Path: []string{"pets"},
+										Item: &resolve.Object{
+											Fields: []*resolve.Field{
+												{
+													Name:  []byte("species"),
+													Value: &resolve.String{Path: []string{"species"}},
+												},
+												{
+													Name:  []byte("name"),
+													Value: &resolve.String{Path: []string{"name"}},
+												},
+											},
+										},
+									},
+								},
+								{
+									Name:  []byte("id"),
+									Value: &resolve.Integer{Path: []string{"id"}},
+								},
+								{
+									Name:        []byte("id"),
+									Value:       &resolve.Integer{Path: []string{"id"}},
+									Deduplicate: true,
+								},
+							},
+						},
+					},
+				},
+			},
+			Extensions: &resolve.Object{
+				Fields: []*resolve.Field{
+					{
+						Name:  []byte("requestId"),
+						Value: &resolve.StaticValue{Value: []byte(`"1"`)},
+					},
+					{
+						Name:  []byte("operationName"),
+						Value: &resolve.StaticValue{Value: []byte(`"Users"`)},
+					},
+				},
+			},
+		},
+	}
+
+	expected := &plan.SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fields: []*resolve.Field{
+					{
+						Name: []byte("user"),
+						Value: &resolve.Object{
+							Path: []string{"user"},
+							Fields: []*resolve.Field{
+								{
+									Name:  []byte("id"),
+									Value: &resolve.Integer{Path: []string{"id"}},
+								},
+								{
+									Name:        []byte("id"),
+									Value:       &resolve.Integer{Path: []string{"id"}},
+									Deduplicate: true,
+								},
+								{
+									Name:  []byte("name"),
+									Value: &resolve.String{Path: []string{"name"}},
+								},
+								{
+									Name: []byte("pets"),
+									Value: &resolve.Array{
+										Path: []string{"pets"},
+										Item: &resolve.Object{
+											Fields: []*resolve.Field{
+												{
+													Name:  []byte("name"),
+													Value: &resolve.String{Path: []string{"name"}},
+												},
+												{
+													Name:  []byte("species"),
+													Value: &resolve.String{Path: []string{"species"}},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Extensions: &resolve.Object{
+				Fields: []*resolve.Field{
+					{
+						Name:  []byte("operationName"),
+						Value: &resolve.StaticValue{Value: []byte(`"Users"`)},
+					},
+					{
+						Name:  []byte("requestId"),
+						Value: &resolve.StaticValue{Value: []byte(`"1"`)},
+					},
+				},
+			},
+		},
+	}
+
+	processor := &ProcessSortFields{}
+	actual := processor.Process(pre)
+
+	assert.Equal(t, expected, actual)
+}